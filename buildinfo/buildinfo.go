@@ -0,0 +1,87 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package buildinfo collects metadata about the code and the CI job a report is generated for.
+// Git information is read directly from the project's .git directory, so no git binary is required.
+package buildinfo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/getgauge/common"
+	"github.com/getgauge/html-report/model"
+)
+
+const fileSuffix = ".build.json"
+
+// Collect returns the git and CI information for the project at projectRoot.
+// Returns nil if neither is available.
+func Collect(projectRoot string) *model.BuildInfo {
+	b := &model.BuildInfo{}
+	if projectRoot != "" {
+		if r, err := openRepo(filepath.Join(projectRoot, ".git")); err == nil {
+			b.Commit, b.Branch = r.head()
+			b.Dirty = r.isDirty(projectRoot)
+		}
+	}
+	readCI(b)
+	if *b == (model.BuildInfo{}) {
+		return nil
+	}
+	return b
+}
+
+// File returns the file the build information of the saved execution result resultFile is kept in.
+func File(resultFile string) string {
+	return resultFile + fileSuffix
+}
+
+// Save keeps b with the saved execution result resultFile, so that reports generated from it
+// later show the build it was executed for. A nil b removes the build information kept before.
+func Save(resultFile string, b *model.BuildInfo) error {
+	if b == nil {
+		if err := os.Remove(File(resultFile)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(resultFile), common.NewDirectoryPermissions); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(File(resultFile), data, common.NewFilePermissions)
+}
+
+// Load returns the build information kept with the saved execution result resultFile.
+// Returns nil if there is none.
+func Load(resultFile string) *model.BuildInfo {
+	data, err := ioutil.ReadFile(File(resultFile))
+	if err != nil {
+		return nil
+	}
+	b := &model.BuildInfo{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil
+	}
+	return b
+}
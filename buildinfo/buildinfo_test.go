@@ -0,0 +1,141 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package buildinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getgauge/html-report/model"
+)
+
+// setEnv sets the given variables after clearing those of all known CI servers,
+// and returns a func to restore the environment.
+func setEnv(vars map[string]string) func() {
+	saved := make(map[string]string)
+	for _, s := range ciServers {
+		for _, n := range append([]string{s.detect}, s.commit...) {
+			if v, ok := os.LookupEnv(n); ok {
+				saved[n] = v
+				os.Unsetenv(n)
+			}
+		}
+	}
+	for n, v := range vars {
+		if old, ok := os.LookupEnv(n); ok {
+			saved[n] = old
+		}
+		os.Setenv(n, v)
+	}
+	return func() {
+		for n := range vars {
+			os.Unsetenv(n)
+		}
+		for n, v := range saved {
+			os.Setenv(n, v)
+		}
+	}
+}
+
+func TestCollectReadsGitAndCI(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{"README.md": "readme"})
+	defer os.RemoveAll(dir)
+	defer setEnv(map[string]string{
+		"JENKINS_URL":  "https://ci.example",
+		"GIT_COMMIT":   packedCommit,
+		"BUILD_NUMBER": "42",
+		"BUILD_URL":    "https://ci.example/job/42",
+		"NODE_NAME":    "agent-1",
+	})()
+
+	got := Collect(dir)
+
+	want := &model.BuildInfo{Commit: mainCommit, Branch: "main", CI: "Jenkins", BuildNumber: "42", JobURL: "https://ci.example/job/42", AgentName: "agent-1"}
+	if *got != *want {
+		t.Errorf("want: %+v, got: %+v", want, got)
+	}
+}
+
+func TestCollectTakesCommitFromCIWithoutRepository(t *testing.T) {
+	defer setEnv(map[string]string{
+		"GITHUB_ACTIONS":    "true",
+		"GITHUB_SHA":        packedCommit,
+		"GITHUB_REF_NAME":   "main",
+		"GITHUB_RUN_NUMBER": "7",
+		"GITHUB_SERVER_URL": "https://github.com",
+		"GITHUB_REPOSITORY": "getgauge/html-report",
+		"GITHUB_RUN_ID":     "123",
+	})()
+
+	got := Collect(filepath.Join("_testdata", "missing"))
+
+	want := &model.BuildInfo{Commit: packedCommit, Branch: "main", CI: "GitHub Actions", BuildNumber: "7", JobURL: "https://github.com/getgauge/html-report/actions/runs/123"}
+	if *got != *want {
+		t.Errorf("want: %+v, got: %+v", want, got)
+	}
+}
+
+func TestCollectWithoutGitAndCI(t *testing.T) {
+	defer setEnv(nil)()
+
+	if got := Collect(""); got != nil {
+		t.Errorf("Expected no build info, got %+v", got)
+	}
+}
+
+func TestSaveKeepsBuildWithResult(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buildinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	resultFile := filepath.Join(dir, "last_run_result")
+	want := &model.BuildInfo{Commit: mainCommit, Branch: "main", CI: "Jenkins"}
+
+	if err := Save(resultFile, want); err != nil {
+		t.Fatalf("Expected error to be nil. Got: %s", err.Error())
+	}
+
+	got := Load(resultFile)
+	if got == nil || *got != *want {
+		t.Errorf("want: %+v, got: %+v", want, got)
+	}
+}
+
+func TestSaveWithoutBuildRemovesKeptBuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buildinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	resultFile := filepath.Join(dir, "last_run_result")
+	Save(resultFile, &model.BuildInfo{Commit: mainCommit})
+
+	if err := Save(resultFile, nil); err != nil {
+		t.Fatalf("Expected error to be nil. Got: %s", err.Error())
+	}
+
+	if got := Load(resultFile); got != nil {
+		t.Errorf("Expected no build, got: %+v", got)
+	}
+	if err := Save(resultFile, nil); err != nil {
+		t.Errorf("Expected no error when nothing is kept. Got: %s", err.Error())
+	}
+}
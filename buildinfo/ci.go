@@ -0,0 +1,148 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package buildinfo
+
+import (
+	"os"
+	"strings"
+
+	"github.com/getgauge/html-report/model"
+)
+
+// ciServer describes the environment variables a CI server sets for a job.
+// Each field lists the variables to try, in order; jobURLFunc is used for servers
+// that do not expose the job url in a single variable.
+type ciServer struct {
+	name        string
+	detect      string
+	commit      []string
+	branch      []string
+	buildNumber []string
+	jobURL      []string
+	jobURLFunc  func() string
+	agentName   []string
+}
+
+var ciServers = []ciServer{
+	{
+		name:        "GitHub Actions",
+		detect:      "GITHUB_ACTIONS",
+		commit:      []string{"GITHUB_SHA"},
+		branch:      []string{"GITHUB_HEAD_REF", "GITHUB_REF_NAME"},
+		buildNumber: []string{"GITHUB_RUN_NUMBER"},
+		jobURLFunc:  gitHubRunURL,
+		agentName:   []string{"RUNNER_NAME"},
+	},
+	{
+		name:        "GitLab CI",
+		detect:      "GITLAB_CI",
+		commit:      []string{"CI_COMMIT_SHA"},
+		branch:      []string{"CI_COMMIT_REF_NAME"},
+		buildNumber: []string{"CI_PIPELINE_IID", "CI_PIPELINE_ID"},
+		jobURL:      []string{"CI_JOB_URL"},
+		agentName:   []string{"CI_RUNNER_DESCRIPTION"},
+	},
+	{
+		name:        "GoCD",
+		detect:      "GO_SERVER_URL",
+		commit:      []string{"GO_REVISION"},
+		buildNumber: []string{"GO_PIPELINE_COUNTER"},
+		agentName:   []string{"GO_AGENT_NAME"},
+	},
+	{
+		name:        "Travis CI",
+		detect:      "TRAVIS",
+		commit:      []string{"TRAVIS_COMMIT"},
+		branch:      []string{"TRAVIS_PULL_REQUEST_BRANCH", "TRAVIS_BRANCH"},
+		buildNumber: []string{"TRAVIS_BUILD_NUMBER"},
+		jobURL:      []string{"TRAVIS_JOB_WEB_URL"},
+	},
+	{
+		name:        "CircleCI",
+		detect:      "CIRCLECI",
+		commit:      []string{"CIRCLE_SHA1"},
+		branch:      []string{"CIRCLE_BRANCH"},
+		buildNumber: []string{"CIRCLE_BUILD_NUM"},
+		jobURL:      []string{"CIRCLE_BUILD_URL"},
+	},
+	{
+		name:        "Azure Pipelines",
+		detect:      "TF_BUILD",
+		commit:      []string{"BUILD_SOURCEVERSION"},
+		branch:      []string{"BUILD_SOURCEBRANCHNAME"},
+		buildNumber: []string{"BUILD_BUILDNUMBER"},
+		agentName:   []string{"AGENT_NAME"},
+	},
+	{
+		name:        "TeamCity",
+		detect:      "TEAMCITY_VERSION",
+		buildNumber: []string{"BUILD_NUMBER"},
+		agentName:   []string{"AGENT_NAME"},
+	},
+	{
+		name:        "Jenkins",
+		detect:      "JENKINS_URL",
+		commit:      []string{"GIT_COMMIT"},
+		branch:      []string{"BRANCH_NAME", "GIT_BRANCH"},
+		buildNumber: []string{"BUILD_NUMBER"},
+		jobURL:      []string{"BUILD_URL"},
+		agentName:   []string{"NODE_NAME"},
+	},
+}
+
+// readCI fills in the details of the CI job the report is generated in. Commit and branch
+// are only taken from the environment if they could not be read from the repository, as
+// CI servers often check out a detached head.
+func readCI(b *model.BuildInfo) {
+	for _, s := range ciServers {
+		if os.Getenv(s.detect) == "" {
+			continue
+		}
+		b.CI = s.name
+		if b.Commit == "" {
+			b.Commit = firstEnv(s.commit)
+		}
+		if b.Branch == "" {
+			b.Branch = firstEnv(s.branch)
+		}
+		b.BuildNumber = firstEnv(s.buildNumber)
+		b.JobURL = firstEnv(s.jobURL)
+		b.AgentName = firstEnv(s.agentName)
+		if s.jobURLFunc != nil {
+			b.JobURL = s.jobURLFunc()
+		}
+		return
+	}
+}
+
+func gitHubRunURL() string {
+	server, repo, run := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID")
+	if server == "" || repo == "" || run == "" {
+		return ""
+	}
+	return strings.TrimSuffix(server, "/") + "/" + repo + "/actions/runs/" + run
+}
+
+func firstEnv(names []string) string {
+	for _, n := range names {
+		if v := strings.TrimSpace(os.Getenv(n)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,298 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package buildinfo
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	refPrefix      = "ref:"
+	branchPrefix   = "refs/heads/"
+	maxSymrefDepth = 5
+
+	indexSignature  = "DIRC"
+	indexEntrySize  = 62
+	assumeValidFlag = 0x8000
+	extendedFlag    = 0x4000
+	skipWorktree    = 0x4000
+	modeTypeMask    = 0170000
+	modeSymlink     = 0120000
+	modeGitlink     = 0160000
+)
+
+var errCorruptIndex = errors.New("corrupt git index")
+
+// repo is a git repository on disk. gitDir holds the files of the working tree (HEAD, index)
+// and commonDir the ones shared between worktrees (refs, packed-refs).
+type repo struct {
+	gitDir    string
+	commonDir string
+}
+
+// openRepo opens the repository at dotGit, which is either the .git directory or, for
+// worktrees and submodules, a file pointing to it.
+func openRepo(dotGit string) (*repo, error) {
+	fi, err := os.Stat(dotGit)
+	if err != nil {
+		return nil, err
+	}
+	gitDir := dotGit
+	if !fi.IsDir() {
+		b, err := ioutil.ReadFile(dotGit)
+		if err != nil {
+			return nil, err
+		}
+		line := strings.TrimSpace(string(b))
+		if !strings.HasPrefix(line, "gitdir:") {
+			return nil, fmt.Errorf("%s is not a git directory", dotGit)
+		}
+		gitDir = resolvePath(filepath.Dir(dotGit), strings.TrimPrefix(line, "gitdir:"))
+	}
+	if _, err := os.Stat(filepath.Join(gitDir, "HEAD")); err != nil {
+		return nil, err
+	}
+	r := &repo{gitDir: gitDir, commonDir: gitDir}
+	if b, err := ioutil.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		r.commonDir = resolvePath(gitDir, string(b))
+	}
+	return r, nil
+}
+
+func resolvePath(base, p string) string {
+	p = filepath.FromSlash(strings.TrimSpace(p))
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(base, p)
+}
+
+// head returns the commit checked out and the current branch, which is empty for a detached head.
+func (r *repo) head() (commit, branch string) {
+	b, err := ioutil.ReadFile(filepath.Join(r.gitDir, "HEAD"))
+	if err != nil {
+		return "", ""
+	}
+	h := strings.TrimSpace(string(b))
+	if !strings.HasPrefix(h, refPrefix) {
+		return h, ""
+	}
+	ref := strings.TrimSpace(strings.TrimPrefix(h, refPrefix))
+	return r.resolve(ref), strings.TrimPrefix(ref, branchPrefix)
+}
+
+// resolve returns the commit a ref points to, following symbolic refs. Returns an
+// empty string for refs that do not exist yet, like the branch of a repository without commits.
+func (r *repo) resolve(ref string) string {
+	for i := 0; i < maxSymrefDepth; i++ {
+		v, ok := r.readLooseRef(ref)
+		if !ok {
+			return r.readPackedRef(ref)
+		}
+		if !strings.HasPrefix(v, refPrefix) {
+			return v
+		}
+		ref = strings.TrimSpace(strings.TrimPrefix(v, refPrefix))
+	}
+	return ""
+}
+
+func (r *repo) readLooseRef(ref string) (string, bool) {
+	for _, dir := range []string{r.gitDir, r.commonDir} {
+		b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(ref)))
+		if err == nil {
+			return strings.TrimSpace(string(b)), true
+		}
+	}
+	return "", false
+}
+
+func (r *repo) readPackedRef(ref string) string {
+	f, err := os.Open(filepath.Join(r.commonDir, "packed-refs"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == ref {
+			return fields[0]
+		}
+	}
+	return ""
+}
+
+// isDirty reports whether any tracked file in worktree differs from the index. Files whose
+// size and modification time match the index are assumed unchanged, like git does. Untracked
+// files are not considered. Returns false if the index cannot be read.
+func (r *repo) isDirty(worktree string) bool {
+	entries, err := readIndex(filepath.Join(r.gitDir, "index"))
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if e.isModified(worktree) {
+			return true
+		}
+	}
+	return false
+}
+
+type indexEntry struct {
+	mtimeSec  uint32
+	mtimeNsec uint32
+	mode      uint32
+	size      uint32
+	hash      [sha1.Size]byte
+	stage     uint16
+	skip      bool
+	path      string
+}
+
+func (e *indexEntry) isModified(worktree string) bool {
+	if e.stage != 0 {
+		return true
+	}
+	if e.skip || e.mode&modeTypeMask == modeGitlink {
+		return false
+	}
+	p := filepath.Join(worktree, filepath.FromSlash(e.path))
+	fi, err := os.Lstat(p)
+	if err != nil {
+		return true
+	}
+	if e.mode&modeTypeMask == modeSymlink {
+		target, err := os.Readlink(p)
+		return err != nil || blobHash([]byte(filepath.ToSlash(target))) != e.hash
+	}
+	if !fi.Mode().IsRegular() || uint32(fi.Size()) != e.size {
+		return true
+	}
+	mtime := fi.ModTime()
+	if uint32(mtime.Unix()) == e.mtimeSec && uint32(mtime.Nanosecond()) == e.mtimeNsec {
+		return false
+	}
+	b, err := ioutil.ReadFile(p)
+	return err != nil || blobHash(b) != e.hash
+}
+
+func blobHash(content []byte) [sha1.Size]byte {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	var sum [sha1.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// readIndex reads the entries of a version 2, 3 or 4 git index file. Extensions are ignored.
+func readIndex(p string) ([]*indexEntry, error) {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 12 || string(b[:4]) != indexSignature {
+		return nil, errCorruptIndex
+	}
+	version := binary.BigEndian.Uint32(b[4:8])
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("unsupported git index version %d", version)
+	}
+	count := int(binary.BigEndian.Uint32(b[8:12]))
+	entries := make([]*indexEntry, 0, count)
+	off, prev := 12, ""
+	for i := 0; i < count; i++ {
+		start := off
+		if off+indexEntrySize > len(b) {
+			return nil, errCorruptIndex
+		}
+		e := &indexEntry{
+			mtimeSec:  binary.BigEndian.Uint32(b[off+8:]),
+			mtimeNsec: binary.BigEndian.Uint32(b[off+12:]),
+			mode:      binary.BigEndian.Uint32(b[off+24:]),
+			size:      binary.BigEndian.Uint32(b[off+36:]),
+		}
+		copy(e.hash[:], b[off+40:off+60])
+		flags := binary.BigEndian.Uint16(b[off+60:])
+		e.stage = (flags >> 12) & 3
+		e.skip = flags&assumeValidFlag != 0
+		off += indexEntrySize
+		if version >= 3 && flags&extendedFlag != 0 {
+			if off+2 > len(b) {
+				return nil, errCorruptIndex
+			}
+			e.skip = e.skip || binary.BigEndian.Uint16(b[off:])&skipWorktree != 0
+			off += 2
+		}
+		name := ""
+		if version == 4 {
+			strip, n := readOffset(b[off:])
+			if n == 0 || strip > len(prev) {
+				return nil, errCorruptIndex
+			}
+			off += n
+			name = prev[:len(prev)-strip]
+		}
+		end := bytes.IndexByte(b[off:], 0)
+		if end < 0 {
+			return nil, errCorruptIndex
+		}
+		e.path = name + string(b[off:off+end])
+		off += end + 1
+		if version < 4 {
+			// entries are padded with NULs to a multiple of 8 bytes
+			off = start + (off-start+7)&^7
+		}
+		prev = e.path
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// readOffset decodes the variable length integer used for path prefixes in version 4 indexes.
+// Returns the value and the number of bytes read, which is 0 if b is truncated.
+func readOffset(b []byte) (int, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	c := b[0]
+	val, n := int(c&0x7f), 1
+	for c&0x80 != 0 {
+		if n >= len(b) {
+			return 0, 0
+		}
+		c = b[n]
+		n++
+		val = ((val + 1) << 7) | int(c&0x7f)
+	}
+	return val, n
+}
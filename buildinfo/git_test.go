@@ -0,0 +1,253 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package buildinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	mainCommit   = "0123456789abcdef0123456789abcdef01234567"
+	packedCommit = "89abcdef0123456789abcdef0123456789abcdef"
+)
+
+func writeFile(t *testing.T, p, content string) {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestRepo creates a repository on branch main with the given tracked files.
+func newTestRepo(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "buildinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, ".git", "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(dir, ".git", "refs", "heads", "main"), mainCommit+"\n")
+	paths := make([]string, 0)
+	for p, c := range files {
+		writeFile(t, filepath.Join(dir, filepath.FromSlash(p)), c)
+		paths = append(paths, p)
+	}
+	writeIndex(t, dir, paths)
+	return dir
+}
+
+// writeIndex writes a version 2 index of the given paths, with their current size and mtime.
+func writeIndex(t *testing.T, dir string, paths []string) {
+	var b bytes.Buffer
+	b.WriteString(indexSignature)
+	binary.Write(&b, binary.BigEndian, uint32(2))
+	binary.Write(&b, binary.BigEndian, uint32(len(paths)))
+	for _, p := range paths {
+		fi, err := os.Stat(filepath.Join(dir, filepath.FromSlash(p)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(p)))
+		start := b.Len()
+		stat := []uint32{0, 0, uint32(fi.ModTime().Unix()), uint32(fi.ModTime().Nanosecond()), 0, 0, 0100644, 0, 0, uint32(fi.Size())}
+		binary.Write(&b, binary.BigEndian, stat)
+		h := blobHash(content)
+		b.Write(h[:])
+		binary.Write(&b, binary.BigEndian, uint16(len(p)))
+		b.WriteString(p)
+		b.Write(make([]byte, (indexEntrySize+len(p)+8)&^7-(b.Len()-start)))
+	}
+	writeFile(t, filepath.Join(dir, ".git", "index"), b.String())
+}
+
+func TestHeadResolvesBranch(t *testing.T) {
+	dir := newTestRepo(t, nil)
+	defer os.RemoveAll(dir)
+
+	r, err := openRepo(filepath.Join(dir, ".git"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, branch := r.head()
+
+	if commit != mainCommit || branch != "main" {
+		t.Errorf("want: %s (main), got: %s (%s)", mainCommit, commit, branch)
+	}
+}
+
+func TestHeadResolvesPackedRef(t *testing.T) {
+	dir := newTestRepo(t, nil)
+	defer os.RemoveAll(dir)
+	writeFile(t, filepath.Join(dir, ".git", "HEAD"), "ref: refs/heads/feature/foo\n")
+	writeFile(t, filepath.Join(dir, ".git", "packed-refs"), "# pack-refs with: peeled fully-peeled sorted\n"+
+		mainCommit+" refs/heads/main\n"+packedCommit+" refs/heads/feature/foo\n^"+mainCommit+"\n")
+
+	r, _ := openRepo(filepath.Join(dir, ".git"))
+	commit, branch := r.head()
+
+	if commit != packedCommit || branch != "feature/foo" {
+		t.Errorf("want: %s (feature/foo), got: %s (%s)", packedCommit, commit, branch)
+	}
+}
+
+func TestHeadWhenDetached(t *testing.T) {
+	dir := newTestRepo(t, nil)
+	defer os.RemoveAll(dir)
+	writeFile(t, filepath.Join(dir, ".git", "HEAD"), packedCommit+"\n")
+
+	r, _ := openRepo(filepath.Join(dir, ".git"))
+	commit, branch := r.head()
+
+	if commit != packedCommit || branch != "" {
+		t.Errorf("want: %s (), got: %s (%s)", packedCommit, commit, branch)
+	}
+}
+
+func TestHeadOfLinkedWorktree(t *testing.T) {
+	dir := newTestRepo(t, nil)
+	defer os.RemoveAll(dir)
+	wtGitDir := filepath.Join(dir, ".git", "worktrees", "wt")
+	writeFile(t, filepath.Join(wtGitDir, "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(wtGitDir, "commondir"), "../..\n")
+	wt := filepath.Join(dir, "wt")
+	writeFile(t, filepath.Join(wt, ".git"), "gitdir: "+wtGitDir+"\n")
+
+	r, err := openRepo(filepath.Join(wt, ".git"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, branch := r.head()
+
+	if commit != mainCommit || branch != "main" {
+		t.Errorf("want: %s (main), got: %s (%s)", mainCommit, commit, branch)
+	}
+}
+
+func TestOpenRepoWithoutGitDirectory(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "buildinfo")
+	defer os.RemoveAll(dir)
+
+	if _, err := openRepo(filepath.Join(dir, ".git")); err == nil {
+		t.Errorf("Expected an error for a directory that is not a repository")
+	}
+}
+
+func TestIsDirty(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(dir string)
+		want   bool
+	}{
+		{"unchanged", func(dir string) {}, false},
+		{"touched", func(dir string) {
+			later := time.Now().Add(time.Hour)
+			os.Chtimes(filepath.Join(dir, "specs", "a.spec"), later, later)
+		}, false},
+		{"modified", func(dir string) {
+			later := time.Now().Add(time.Hour)
+			ioutil.WriteFile(filepath.Join(dir, "specs", "a.spec"), []byte("# B"), 0644)
+			os.Chtimes(filepath.Join(dir, "specs", "a.spec"), later, later)
+		}, true},
+		{"resized", func(dir string) {
+			ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("readme!"), 0644)
+		}, true},
+		{"deleted", func(dir string) {
+			os.Remove(filepath.Join(dir, "README.md"))
+		}, true},
+	}
+	for _, test := range tests {
+		dir := newTestRepo(t, map[string]string{"specs/a.spec": "# A", "README.md": "readme"})
+		test.change(dir)
+
+		r, _ := openRepo(filepath.Join(dir, ".git"))
+		got := r.isDirty(dir)
+
+		if got != test.want {
+			t.Errorf("%s - want: %t, got: %t", test.name, test.want, got)
+		}
+		os.RemoveAll(dir)
+	}
+}
+
+func TestReadIndexVersion4(t *testing.T) {
+	var b bytes.Buffer
+	b.WriteString(indexSignature)
+	binary.Write(&b, binary.BigEndian, uint32(4))
+	binary.Write(&b, binary.BigEndian, uint32(2))
+	for _, e := range []struct {
+		strip int
+		name  string
+	}{{0, "specs/a.spec"}, {6, "b.spec"}} {
+		b.Write(make([]byte, 60))
+		binary.Write(&b, binary.BigEndian, uint16(0))
+		b.WriteByte(byte(e.strip))
+		b.WriteString(e.name)
+		b.WriteByte(0)
+	}
+	dir, _ := ioutil.TempDir("", "buildinfo")
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, "index")
+	ioutil.WriteFile(p, b.Bytes(), 0644)
+
+	entries, err := readIndex(p)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].path != "specs/a.spec" || entries[1].path != "specs/b.spec" {
+		t.Errorf("Unexpected entries %v", entries)
+	}
+}
+
+func TestReadIndexRejectsTruncatedIndex(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{"README.md": "readme"})
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, ".git", "index")
+	b, _ := ioutil.ReadFile(p)
+	ioutil.WriteFile(p, b[:len(b)-20], 0644)
+
+	if _, err := readIndex(p); err == nil {
+		t.Errorf("Expected an error for a truncated index")
+	}
+}
+
+func TestReadOffset(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		val  int
+		read int
+	}{
+		{[]byte{0x05}, 5, 1},
+		{[]byte{0x80, 0x00}, 128, 2},
+		{[]byte{0x81, 0x7f}, 383, 2},
+		{[]byte{0x80}, 0, 0},
+	}
+	for _, test := range tests {
+		val, read := readOffset(test.in)
+		if val != test.val || read != test.read {
+			t.Errorf("%v - want: (%d, %d), got: (%d, %d)", test.in, test.val, test.read, val, read)
+		}
+	}
+}
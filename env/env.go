@@ -23,6 +23,8 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/getgauge/common"
@@ -33,6 +35,23 @@ const (
 	GaugeReportsDirEnvName      = "gauge_reports_dir" // directory where reports are generated by plugins
 	OverwriteReportsEnvProperty = "overwrite_reports"
 	UseNestedSpecs              = "use_nested_specs"
+	ReportFormats               = "html_report_formats"
+	Concurrency                 = "html_report_concurrency"
+	SourceURL                   = "html_report_source_url"
+	BuildInfo                   = "html_report_build_info"
+	ShowSource                  = "html_report_show_source"
+	ConsoleSummary              = "html_report_console_summary"
+	MarkdownMaxSize             = "html_report_markdown_max_size"
+	StreamFormat                = "html_report_stream"
+	StreamFile                  = "html_report_stream_file"
+	SARIFFailures               = "html_report_sarif_failures"
+	MetricsFile                 = "html_report_metrics_file"
+	MetricsLabels               = "html_report_metrics_labels"
+	MetricsMaxSeries            = "html_report_metrics_max_series"
+	defaultReportFormat         = "html"
+	defaultMarkdownMaxSize      = 65536
+	defaultMetricsLabels        = "spec,tag"
+	defaultMetricsMaxSeries     = 100
 )
 
 func GetCurrentExecutableDir() (string, string) {
@@ -107,3 +126,129 @@ func ShouldUseNestedSpecs() bool {
 	}
 	return false
 }
+
+// GetReportFormats returns the report formats configured via the html_report_formats property.
+// Defaults to html when the property is not set.
+func GetReportFormats() []string {
+	formats := ParseReportFormats(os.Getenv(ReportFormats))
+	if len(formats) == 0 {
+		return []string{defaultReportFormat}
+	}
+	return formats
+}
+
+// ParseReportFormats splits a comma separated list of report formats, dropping blanks and duplicates.
+func ParseReportFormats(value string) []string {
+	formats := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, f := range strings.Split(value, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" || seen[f] {
+			continue
+		}
+		seen[f] = true
+		formats = append(formats, f)
+	}
+	return formats
+}
+
+// GetConcurrency returns the number of spec pages that may be rendered in parallel, set via the
+// html_report_concurrency property. Defaults to the number of CPUs.
+func GetConcurrency() int {
+	n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(Concurrency)))
+	if err != nil || n <= 0 {
+		return runtime.NumCPU()
+	}
+	return n
+}
+
+// GetSourceURLTemplate returns the template used to link specs to their source, set via the
+// html_report_source_url property, e.g. https://git.example/repo/blob/{commit}/{path}#L{line}.
+func GetSourceURLTemplate() string {
+	return strings.TrimSpace(os.Getenv(SourceURL))
+}
+
+// ShouldCollectBuildInfo tells whether git and CI metadata should be added to the report.
+// Set html_report_build_info to false to leave it out.
+func ShouldCollectBuildInfo() bool {
+	return strings.ToLower(strings.TrimSpace(os.Getenv(BuildInfo))) != "false"
+}
+
+// ShouldShowSource tells whether spec pages should have a tab with the source of the spec,
+// set via the html_report_show_source property.
+func ShouldShowSource() bool {
+	return strings.ToLower(strings.TrimSpace(os.Getenv(ShowSource))) == "true"
+}
+
+// GetConsoleSummary returns how the summary printed after execution is styled, set via the
+// html_report_console_summary property to off, plain, ansi or auto. Defaults to auto, which
+// colors the summary only when it is printed to a terminal.
+func GetConsoleSummary() string {
+	switch v := strings.ToLower(strings.TrimSpace(os.Getenv(ConsoleSummary))); v {
+	case "off", "plain", "ansi":
+		return v
+	default:
+		return "auto"
+	}
+}
+
+// GetMarkdownMaxSize returns the size in bytes the markdown summary is kept within, set via the
+// html_report_markdown_max_size property. Defaults to 65536, the size limit of a GitHub comment.
+func GetMarkdownMaxSize() int {
+	n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(MarkdownMaxSize)))
+	if err != nil || n <= 0 {
+		return defaultMarkdownMaxSize
+	}
+	return n
+}
+
+// GetStreamFormat returns the format the progress of an execution is streamed in, tap or teamcity,
+// set via the html_report_stream property. Progress is not streamed when it is not set.
+func GetStreamFormat() string {
+	return strings.ToLower(strings.TrimSpace(os.Getenv(StreamFormat)))
+}
+
+// GetStreamFile returns the file the progress of an execution is streamed to, set via the
+// html_report_stream_file property. Progress is streamed to stdout when it is not set.
+func GetStreamFile() string {
+	return strings.TrimSpace(os.Getenv(StreamFile))
+}
+
+// ShouldReportFailuresInSARIF tells whether failed steps and hooks are reported in the SARIF log
+// along with parse and validation errors, set via the html_report_sarif_failures property.
+func ShouldReportFailuresInSARIF() bool {
+	return strings.ToLower(strings.TrimSpace(os.Getenv(SARIFFailures))) == "true"
+}
+
+// GetMetricsFile returns the file run metrics are written to, set via the html_report_metrics_file
+// property. Empty when it is not set, in which case they are written to the reports directory.
+func GetMetricsFile() string {
+	return strings.TrimSpace(os.Getenv(MetricsFile))
+}
+
+// GetMetricsLabels returns the labels run metrics are broken down by, spec and tag, set as a
+// comma separated list via the html_report_metrics_labels property. Defaults to both, set it to
+// none to only write the totals of the suite.
+func GetMetricsLabels() []string {
+	v := strings.TrimSpace(os.Getenv(MetricsLabels))
+	if v == "" {
+		v = defaultMetricsLabels
+	}
+	labels := make([]string, 0)
+	for _, l := range strings.Split(v, ",") {
+		if l = strings.ToLower(strings.TrimSpace(l)); l != "" {
+			labels = append(labels, l)
+		}
+	}
+	return labels
+}
+
+// GetMetricsMaxSeries returns the number of specs or tags run metrics are broken down by at most,
+// set via the html_report_metrics_max_series property. Defaults to 100.
+func GetMetricsMaxSeries() int {
+	n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(MetricsMaxSeries)))
+	if err != nil || n <= 0 {
+		return defaultMetricsMaxSeries
+	}
+	return n
+}
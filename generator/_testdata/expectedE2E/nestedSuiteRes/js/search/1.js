@@ -0,0 +1 @@
+addSearchShard("search/1.js", {"Specs":[["nested/nested_specification.html","Nested Specification"]],"Docs":[{"s":0,"a":"scenario-1","k":"scenario","t":"Vowel counts in multiple words"},{"s":0,"a":"step-1","k":"step","t":"Context Step1"},{"s":0,"a":"step-2","k":"step","t":"Context Step2"},{"s":0,"a":"step-3","k":"step","t":"Almost all words have vowels\u003ctable\u003e"},{"s":0,"a":"step-4","k":"step","t":"Teardown Step1"},{"s":0,"a":"step-5","k":"step","t":"Teardown Step2"}],"Terms":{"all":[3],"almost":[3],"context":[1,2],"counts":[0],"have":[3],"in":[0],"multiple":[0],"step1":[1,4],"step2":[2,5],"table":[3],"teardown":[4,5],"vowel":[0],"vowels":[3],"words":[0,3]}});
\ No newline at end of file
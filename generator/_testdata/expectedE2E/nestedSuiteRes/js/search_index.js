@@ -1 +1 @@
-var index = {"Tags":{"bar":["passing_specification_1.html"],"foo":["passing_specification_1.html"],"tag1":["passing_specification_1.html"],"tag2":["passing_specification_1.html"]},"Specs":{"Nested Specification":["nested/nested_specification.html"],"Passing Specification 1":["passing_specification_1.html"]}};
\ No newline at end of file
+var index = {"Tags":{"bar":["passing_specification_1.html"],"foo":["passing_specification_1.html"],"tag1":["passing_specification_1.html"],"tag2":["passing_specification_1.html"]},"Specs":{"Nested Specification":["nested/nested_specification.html"],"Passing Specification 1":["passing_specification_1.html"]},"Shards":{".":{"File":"search/0.js","Prefixes":["al","co","ga","ha","he","hi","in","mu","ou","sa","si","st","ta","te","to","vo","wo"]},"nested":{"File":"search/1.js","Prefixes":["al","co","ha","in","mu","st","ta","te","vo","wo"]}}};
\ No newline at end of file
@@ -0,0 +1 @@
+addSearchShard("search/0.js", {"Specs":[["passing_specification_1.html","Passing Specification 1"],["failing_specification_1.html","Failing Specification 1"],["skipped_specification.html","Skipped Specification"]],"Docs":[{"s":0,"a":"scenario-1","k":"scenario","t":"Vowel counts in single word"},{"s":0,"a":"step-1","k":"step","t":"Context Step1"},{"s":0,"a":"step-2","k":"step","t":"Context Step2"},{"s":0,"a":"step-3","k":"step","t":"Step1"},{"s":0,"a":"step-4","k":"step","t":"Say \"hi\" to \"gauge\""},{"s":0,"a":"step-5","k":"step","t":"Concept Heading"},{"s":0,"a":"step-6","k":"step","t":"Concept Step1"},{"s":0,"a":"step-7","k":"step","t":"Concept Step2"},{"s":0,"a":"step-8","k":"step","t":"Outer Concept"},{"s":0,"a":"step-9","k":"step","t":"Outer Concept Step 1"},{"s":0,"a":"step-10","k":"step","t":"Inner Concept"},{"s":0,"a":"step-11","k":"step","t":"Inner Concept Step 1"},{"s":0,"a":"step-12","k":"step","t":"Inner Concept Step 2"},{"s":0,"a":"step-13","k":"step","t":"Outer Concept Step 2"},{"s":0,"a":"step-14","k":"step","t":"Teardown Step1"},{"s":0,"a":"step-15","k":"step","t":"Teardown Step2"},{"s":0,"a":"scenario-2","k":"scenario","t":"Vowel counts in multiple words"},{"s":0,"a":"step-16","k":"step","t":"Context Step1"},{"s":0,"a":"step-17","k":"step","t":"Context Step2"},{"s":0,"a":"step-18","k":"step","t":"Almost all words have vowels\u003ctable\u003e"},{"s":0,"a":"step-19","k":"step","t":"Teardown Step1"},{"s":0,"a":"step-20","k":"step","t":"Teardown Step2"},{"s":1,"a":"scenario-1","k":"scenario","t":"Scenario Heading"},{"s":1,"a":"step-1","k":"step","t":"passing step"},{"s":1,"a":"step-2","k":"step","t":"This is a failing step"},{"s":1,"a":"step-2","k":"error","t":"java.lang.RuntimeException"},{"s":1,"a":"step-3","k":"step","t":"This step is skipped because previous one failed"},{"s":2,"a":"scenario-1","k":"scenario","t":"skipped scenario"},{"s":2,"a":"step-1","k":"step","t":"Context Step"},{"s":2,"a":"step-2","k":"step","t":"skipped step"}],"Terms":{"all":[19],"almost":[19],"because":[26],"concept":[5,6,7,8,9,10,11,12,13],"context":[1,2,17,18,28],"counts":[0,16],"failed":[26],"failing":[24],"gauge":[4],"have":[19],"heading":[5,22],"hi":[4],"in":[0,16],"inner":[10,11,12],"is":[24,26],"java":[25],"lang":[25],"multiple":[16],"one":[26],"outer":[8,9,13],"passing":[23],"previous":[26],"runtimeexception":[25],"say":[4],"scenario":[22,27],"single":[0],"skipped":[26,27,29],"step":[9,11,12,13,23,24,26,28,29],"step1":[1,3,6,14,17,20],"step2":[2,7,15,18,21],"table":[19],"teardown":[14,15,20,21],"this":[24,26],"to":[4],"vowel":[0,16],"vowels":[19],"word":[0],"words":[16,19]}});
\ No newline at end of file
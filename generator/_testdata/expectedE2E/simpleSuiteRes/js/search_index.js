@@ -1 +1 @@
-var index = {"Tags":{"bar":["passing_specification_1.html"],"foo":["passing_specification_1.html"],"tag1":["passing_specification_1.html"],"tag2":["passing_specification_1.html"]},"Specs":{"Failing Specification 1":["failing_specification_1.html"],"Passing Specification 1":["passing_specification_1.html"],"Skipped Specification":["skipped_specification.html"]}};
\ No newline at end of file
+var index = {"Tags":{"bar":["passing_specification_1.html"],"foo":["passing_specification_1.html"],"tag1":["passing_specification_1.html"],"tag2":["passing_specification_1.html"]},"Specs":{"Failing Specification 1":["failing_specification_1.html"],"Passing Specification 1":["passing_specification_1.html"],"Skipped Specification":["skipped_specification.html"]},"Shards":{".":{"File":"search/0.js","Prefixes":["al","be","co","fa","ga","ha","he","hi","in","is","ja","la","mu","on","ou","pa","pr","ru","sa","sc","si","sk","st","ta","te","th","to","vo","wo"]}}};
\ No newline at end of file
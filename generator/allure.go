@@ -0,0 +1,459 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/getgauge/common"
+	"github.com/getgauge/html-report/model"
+)
+
+const (
+	allureFormat       = "allure"
+	allureResultsDir   = "allure-results"
+	allureEnvFile      = "environment.properties"
+	allureExecutorFile = "executor.json"
+	gaugeTimestamp     = "Jan 2, 2006 at 3:04pm"
+)
+
+// allureLabels are the Allure label names a tag like "severity:critical" is mapped to. Other
+// tags become tag labels.
+var allureLabels = map[string]bool{
+	"epic": true, "feature": true, "story": true, "severity": true, "owner": true, "layer": true, "lead": true,
+}
+
+// newAllureUUID names the files of the results, it is replaced in tests.
+var newAllureUUID = func() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// The allure* types follow the files of an Allure results directory.
+type allureResult struct {
+	UUID          string              `json:"uuid"`
+	HistoryID     string              `json:"historyId"`
+	FullName      string              `json:"fullName"`
+	Name          string              `json:"name"`
+	Description   string              `json:"description,omitempty"`
+	Status        string              `json:"status"`
+	StatusDetails *allureStatusDetail `json:"statusDetails,omitempty"`
+	Stage         string              `json:"stage"`
+	Start         int64               `json:"start"`
+	Stop          int64               `json:"stop"`
+	Labels        []allureLabel       `json:"labels"`
+	Parameters    []allureParameter   `json:"parameters,omitempty"`
+	Steps         []*allureStep       `json:"steps"`
+	Attachments   []allureAttachment  `json:"attachments,omitempty"`
+}
+
+type allureStatusDetail struct {
+	Message string `json:"message,omitempty"`
+	Trace   string `json:"trace,omitempty"`
+}
+
+type allureLabel struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type allureParameter struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// allureStep is a step of a result, or a fixture of a container.
+type allureStep struct {
+	Name          string              `json:"name"`
+	Status        string              `json:"status"`
+	StatusDetails *allureStatusDetail `json:"statusDetails,omitempty"`
+	Stage         string              `json:"stage"`
+	Start         int64               `json:"start"`
+	Stop          int64               `json:"stop"`
+	Steps         []*allureStep       `json:"steps"`
+	Attachments   []allureAttachment  `json:"attachments,omitempty"`
+}
+
+type allureAttachment struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Type   string `json:"type"`
+}
+
+type allureContainer struct {
+	UUID     string        `json:"uuid"`
+	Name     string        `json:"name"`
+	Children []string      `json:"children"`
+	Befores  []*allureStep `json:"befores"`
+	Afters   []*allureStep `json:"afters"`
+	Start    int64         `json:"start"`
+	Stop     int64         `json:"stop"`
+}
+
+type allureExecutor struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	BuildName  string `json:"buildName,omitempty"`
+	BuildURL   string `json:"buildUrl,omitempty"`
+	BuildOrder string `json:"buildOrder,omitempty"`
+}
+
+type allureExporter struct {
+}
+
+// Export replaces the allure-results directory in reportsDir with the results of res.
+func (e *allureExporter) Export(res *SuiteResult, reportsDir string) error {
+	dir := filepath.Join(reportsDir, allureResultsDir)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, common.NewDirectoryPermissions); err != nil {
+		return err
+	}
+	w := &allureWriter{dir: dir, clock: allureStart(res.Timestamp)}
+	w.writeSuite(res)
+	w.writeFile(allureEnvFile, []byte(toAllureEnvironment(res)))
+	if executor := toAllureExecutor(res.Build); executor != nil {
+		w.writeJSON(allureExecutorFile, executor)
+	}
+	if w.err != nil {
+		return w.err
+	}
+	fmt.Printf("Successfully generated allure results to => %s\n", dir)
+	return nil
+}
+
+// allureStart is the time the suite started at, according to the timestamp Gauge reports.
+func allureStart(timestamp string) int64 {
+	t, err := time.ParseInLocation(gaugeTimestamp, timestamp, time.Local)
+	if err != nil {
+		t = time.Now()
+	}
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// allureWriter writes the results of a suite. Gauge reports how long things took but not when
+// they started, so clock lays them out one after the other from the start of the suite.
+type allureWriter struct {
+	dir   string
+	clock int64
+	err   error
+}
+
+func (w *allureWriter) writeSuite(res *SuiteResult) {
+	suite := &allureContainer{UUID: newAllureUUID(), Name: res.ProjectName, Children: make([]string, 0), Start: w.clock}
+	if h := res.BeforeSuiteHookFailure; h != nil {
+		suite.Befores = append(suite.Befores, w.toFixture(h))
+	}
+	for _, s := range res.SpecResults {
+		if s != nil {
+			suite.Children = append(suite.Children, w.writeSpec(res, s)...)
+		}
+	}
+	if h := res.AfterSuiteHookFailure; h != nil {
+		suite.Afters = append(suite.Afters, w.toFixture(h))
+	}
+	suite.Stop = w.clock
+	w.writeJSON(suite.UUID+"-container.json", suite)
+}
+
+// writeSpec writes the results of the scenarios of a spec and a container holding its hooks,
+// and returns the uuids of the results.
+func (w *allureWriter) writeSpec(res *SuiteResult, s *spec) []string {
+	container := &allureContainer{UUID: newAllureUUID(), Name: s.SpecHeading, Children: make([]string, 0), Start: w.clock}
+	for _, h := range s.BeforeSpecHookFailures {
+		container.Befores = append(container.Befores, w.toFixture(h))
+	}
+	for _, scn := range s.Scenarios {
+		r := w.toResult(res, s, scn)
+		w.writeJSON(r.UUID+"-result.json", r)
+		container.Children = append(container.Children, r.UUID)
+	}
+	for _, h := range s.AfterSpecHookFailures {
+		container.Afters = append(container.Afters, w.toFixture(h))
+	}
+	container.Stop = w.clock
+	w.writeJSON(container.UUID+"-container.json", container)
+	return container.Children
+}
+
+func (w *allureWriter) toResult(res *SuiteResult, s *spec, scn *scenario) *allureResult {
+	fullName := relativeSpecPath(res.ProjectRoot, s.FileName) + "#" + scn.Heading
+	r := &allureResult{
+		UUID:     newAllureUUID(),
+		FullName: fullName,
+		Name:     scn.Heading,
+		Status:   allureStatus(scn.ExecutionStatus),
+		Stage:    "finished",
+		Start:    w.clock,
+		Labels:   toAllureLabels(res, s, scn),
+		Steps:    make([]*allureStep, 0),
+	}
+	if scn.TableRowIndex >= 0 && s.Datatable != nil {
+		if row := tableRow(s.Datatable, scn.TableRowIndex); row != nil {
+			for i, h := range s.Datatable.Headers {
+				if i < len(row.Cells) {
+					r.Parameters = append(r.Parameters, allureParameter{Name: h, Value: row.Cells[i]})
+				}
+			}
+		}
+	}
+	r.HistoryID = allureHistoryID(fullName, r.Parameters)
+	if msg := firstScenarioError(scn); msg != "" {
+		r.StatusDetails = &allureStatusDetail{Message: msg, Trace: firstScenarioStackTrace(scn)}
+	}
+	if h := scn.BeforeScenarioHookFailure; h != nil {
+		r.Steps = append(r.Steps, w.toFixture(h))
+	}
+	for _, items := range [][]item{scn.Contexts, scn.Items, scn.Teardowns} {
+		r.Steps = append(r.Steps, w.toSteps(items)...)
+	}
+	if h := scn.AfterScenarioHookFailure; h != nil {
+		r.Steps = append(r.Steps, w.toFixture(h))
+	}
+	if stop := r.Start + scn.Duration; stop > w.clock {
+		w.clock = stop
+	}
+	r.Stop = w.clock
+	return r
+}
+
+func (w *allureWriter) toSteps(items []item) []*allureStep {
+	steps := make([]*allureStep, 0)
+	for _, i := range items {
+		switch i.Kind {
+		case stepKind:
+			if i.Step != nil {
+				steps = append(steps, w.toStep(i.Step, nil))
+			}
+		case conceptKind:
+			if i.Concept != nil && i.Concept.ConceptStep != nil {
+				steps = append(steps, w.toStep(i.Concept.ConceptStep, i.Concept.Items))
+			}
+		}
+	}
+	return steps
+}
+
+// toStep converts a step, or the step of a concept along with the items of the concept as sub-steps.
+func (w *allureWriter) toStep(s *step, conceptItems []item) *allureStep {
+	st := &allureStep{Name: toStepText(s), Status: "skipped", Stage: "finished", Start: w.clock, Steps: make([]*allureStep, 0)}
+	if h := s.BeforeStepHookFailure; h != nil {
+		st.Steps = append(st.Steps, w.toFixture(h))
+	}
+	st.Steps = append(st.Steps, w.toSteps(conceptItems)...)
+	if r := s.Result; r != nil {
+		st.Status = allureStatus(r.Status)
+		if r.ErrorMessage != "" || r.StackTrace != "" {
+			st.StatusDetails = &allureStatusDetail{Message: r.ErrorMessage, Trace: r.StackTrace}
+		}
+		st.Attachments = append(st.Attachments, w.attachScreenshot(r.Screenshot)...)
+		st.Attachments = append(st.Attachments, w.attachText("Stack trace", r.StackTrace)...)
+		st.Attachments = append(st.Attachments, w.attachText("Messages", strings.Join(r.Messages, "\n"))...)
+		if conceptItems == nil {
+			w.clock = st.Start + r.Duration
+		}
+	}
+	if h := s.AfterStepHookFailure; h != nil {
+		st.Steps = append(st.Steps, w.toFixture(h))
+	}
+	st.Stop = w.clock
+	return st
+}
+
+// toFixture converts a hook failure. Failing hooks are reported as broken, as the tests they ran
+// for did not get to check what they are meant to.
+func (w *allureWriter) toFixture(h *hookFailure) *allureStep {
+	f := &allureStep{
+		Name:          h.HookName,
+		Status:        "broken",
+		StatusDetails: &allureStatusDetail{Message: h.ErrMsg, Trace: h.StackTrace},
+		Stage:         "finished",
+		Start:         w.clock,
+		Stop:          w.clock,
+		Steps:         make([]*allureStep, 0),
+	}
+	f.Attachments = append(f.Attachments, w.attachScreenshot(h.Screenshot)...)
+	f.Attachments = append(f.Attachments, w.attachText("Stack trace", h.StackTrace)...)
+	return f
+}
+
+func (w *allureWriter) attachScreenshot(screenshot string) []allureAttachment {
+	if screenshot == "" {
+		return nil
+	}
+	b, err := base64.StdEncoding.DecodeString(screenshot)
+	if err != nil {
+		return nil
+	}
+	mimeType := http.DetectContentType(b)
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = "image/png"
+	}
+	return w.attach("Screenshot", mimeType, "."+strings.TrimPrefix(mimeType, "image/"), b)
+}
+
+func (w *allureWriter) attachText(name, text string) []allureAttachment {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	return w.attach(name, "text/plain", ".txt", []byte(text))
+}
+
+func (w *allureWriter) attach(name, mimeType, ext string, b []byte) []allureAttachment {
+	source := newAllureUUID() + "-attachment" + ext
+	w.writeFile(source, b)
+	return []allureAttachment{{Name: name, Source: source, Type: mimeType}}
+}
+
+func (w *allureWriter) writeJSON(name string, v interface{}) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		if w.err == nil {
+			w.err = err
+		}
+		return
+	}
+	w.writeFile(name, b)
+}
+
+func (w *allureWriter) writeFile(name string, b []byte) {
+	if w.err != nil {
+		return
+	}
+	w.err = ioutil.WriteFile(filepath.Join(w.dir, name), b, common.NewFilePermissions)
+}
+
+func allureStatus(s status) string {
+	switch s {
+	case pass:
+		return "passed"
+	case fail, recoverableFail:
+		return "failed"
+	default:
+		return "skipped"
+	}
+}
+
+// allureHistoryID identifies a test across runs, so that Allure can show its history.
+func allureHistoryID(fullName string, params []allureParameter) string {
+	h := md5.New()
+	h.Write([]byte(fullName))
+	for _, p := range params {
+		h.Write([]byte("\x00" + p.Name + "=" + p.Value))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// toAllureLabels labels a result with its spec, as the feature and suite, and with the tags of
+// the spec and the scenario.
+func toAllureLabels(res *SuiteResult, s *spec, scn *scenario) []allureLabel {
+	labels := []allureLabel{
+		{Name: "framework", Value: "gauge"},
+		{Name: "suite", Value: s.SpecHeading},
+	}
+	if dir := filepath.ToSlash(filepath.Dir(relativeSpecPath(res.ProjectRoot, s.FileName))); dir != "." {
+		labels = append(labels, allureLabel{Name: "parentSuite", Value: dir})
+	}
+	hasFeature := false
+	for _, t := range append(append([]string{}, s.Tags...), scn.Tags...) {
+		l := allureLabel{Name: "tag", Value: t}
+		if i := strings.Index(t, ":"); i > 0 && allureLabels[strings.ToLower(t[:i])] {
+			l = allureLabel{Name: strings.ToLower(t[:i]), Value: strings.TrimSpace(t[i+1:])}
+		}
+		hasFeature = hasFeature || l.Name == "feature"
+		labels = append(labels, l)
+	}
+	if !hasFeature {
+		labels = append(labels, allureLabel{Name: "feature", Value: s.SpecHeading})
+	}
+	if res.Build != nil && res.Build.AgentName != "" {
+		labels = append(labels, allureLabel{Name: "host", Value: res.Build.AgentName})
+	}
+	return labels
+}
+
+// toAllureEnvironment lists the Gauge environment and the build the suite was executed for.
+func toAllureEnvironment(res *SuiteResult) string {
+	props := map[string]string{"Environment": res.Environment, "Project": res.ProjectName, "Tags": res.Tags}
+	if b := res.Build; b != nil {
+		props["Commit"] = b.Commit
+		props["Branch"] = b.Branch
+		props["CI"] = b.CI
+		props["Build number"] = b.BuildNumber
+		props["Job URL"] = b.JobURL
+		props["Agent"] = b.AgentName
+		if b.Dirty {
+			props["Dirty"] = "true"
+		}
+	}
+	keys := make([]string, 0, len(props))
+	for k, v := range props {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var out bytes.Buffer
+	for _, k := range keys {
+		fmt.Fprintf(&out, "%s=%s\n", escapeProperty(k, true), escapeProperty(props[k], false))
+	}
+	return out.String()
+}
+
+// escapeProperty escapes a key or value of a java properties file.
+func escapeProperty(s string, key bool) string {
+	var b bytes.Buffer
+	for _, c := range s {
+		switch {
+		case c == '\\' || c == '=' || c == ':' || c == '#' || c == '!' || (key && c == ' '):
+			b.WriteRune('\\')
+			b.WriteRune(c)
+		case c == '\n':
+			b.WriteString("\\n")
+		case c == '\r':
+			b.WriteString("\\r")
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+func toAllureExecutor(b *model.BuildInfo) *allureExecutor {
+	if b == nil || b.CI == "" {
+		return nil
+	}
+	return &allureExecutor{Name: b.CI, Type: strings.ToLower(strings.Replace(b.CI, " ", "", -1)), BuildName: strings.TrimSpace(b.CI + " #" + b.BuildNumber), BuildURL: b.JobURL, BuildOrder: b.BuildNumber}
+}
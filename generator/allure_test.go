@@ -0,0 +1,162 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getgauge/html-report/model"
+)
+
+func exportAllure(t *testing.T, res *SuiteResult) (string, func()) {
+	uuid := 0
+	defer func(f func() string) { newAllureUUID = f }(newAllureUUID)
+	newAllureUUID = func() string {
+		uuid++
+		return fmt.Sprintf("uuid%d", uuid)
+	}
+	dir, err := ioutil.TempDir("", "allure")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (&allureExporter{}).Export(res, dir); err != nil {
+		t.Fatalf("Expected error to be nil. Got: %s", err.Error())
+	}
+	return filepath.Join(dir, allureResultsDir), func() { os.RemoveAll(dir) }
+}
+
+func readAllureFile(t *testing.T, dir, name string, v interface{}) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("Expected %s to be written. Got: %s", name, err.Error())
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		t.Fatalf("Expected %s to be valid json. Got: %s", name, err.Error())
+	}
+}
+
+func TestAllureExporterWritesScenarioResults(t *testing.T) {
+	res := cucumberSuiteRes()
+	res.Timestamp = "Jul 13, 2016 at 11:49am"
+	res.SpecResults[0].Tags = []string{"severity:critical", "words"}
+	dir, cleanUp := exportAllure(t, res)
+	defer cleanUp()
+	var got allureResult
+
+	readAllureFile(t, dir, "uuid3-result.json", &got)
+
+	if got.Name != "Count words" || got.FullName != "specs/words.spec#Count words" || got.Status != "failed" {
+		t.Errorf("Unexpected result: %+v", got)
+	}
+	checkEqual(t, "parameters", []allureParameter{{Name: "Word", Value: "Gauge"}}, got.Parameters)
+	checkEqual(t, "labels", []allureLabel{
+		{Name: "framework", Value: "gauge"},
+		{Name: "suite", Value: "Word count"},
+		{Name: "parentSuite", Value: "specs"},
+		{Name: "severity", Value: "critical"},
+		{Name: "tag", Value: "words"},
+		{Name: "tag", Value: "fast"},
+		{Name: "feature", Value: "Word count"},
+	}, got.Labels)
+	if len(got.Steps) != 3 || got.Steps[0].Name != "Open app" || got.Steps[1].Name != "Count" || got.Steps[2].Name != "After Scenario" {
+		t.Fatalf("Unexpected steps: %+v", got.Steps)
+	}
+	concept := got.Steps[1]
+	if concept.Status != "failed" || len(concept.Steps) != 1 || concept.Steps[0].Name != "Inner" {
+		t.Errorf("Expected the concept with its steps as sub-steps, got: %+v", concept)
+	}
+	if got.Steps[2].Status != "broken" {
+		t.Errorf("Expected the failed hook to be broken, got: %s", got.Steps[2].Status)
+	}
+	if got.Start != allureStart(res.Timestamp) || got.Stop-got.Start != 42 {
+		t.Errorf("Expected the result to last the time of its steps, got: %d to %d", got.Start, got.Stop)
+	}
+	for _, a := range append(concept.Attachments, concept.Steps[0].Attachments...) {
+		if _, err := os.Stat(filepath.Join(dir, a.Source)); err != nil {
+			t.Errorf("Expected attachment %s to be written", a.Source)
+		}
+	}
+	if len(concept.Steps[0].Attachments) != 2 || concept.Steps[0].Attachments[0].Type != "image/png" || concept.Steps[0].Attachments[1].Name != "Messages" {
+		t.Errorf("Expected the screenshot and messages to be attached, got: %+v", concept.Steps[0].Attachments)
+	}
+}
+
+func TestAllureExporterWritesHooksAsContainers(t *testing.T) {
+	res := cucumberSuiteRes()
+	res.ProjectName = "Gauge Project"
+	res.BeforeSuiteHookFailure = &hookFailure{HookName: "Before Suite", ErrMsg: "no database"}
+	res.SpecResults[0].AfterSpecHookFailures = []*hookFailure{{HookName: "After Spec", ErrMsg: "cleanup failed"}}
+	dir, cleanUp := exportAllure(t, res)
+	defer cleanUp()
+	var suite, spec allureContainer
+
+	readAllureFile(t, dir, "uuid1-container.json", &suite)
+	readAllureFile(t, dir, "uuid2-container.json", &spec)
+
+	if suite.Name != "Gauge Project" || len(suite.Befores) != 1 || suite.Befores[0].StatusDetails.Message != "no database" {
+		t.Errorf("Unexpected suite container: %+v", suite)
+	}
+	checkEqual(t, "suite children", []string{"uuid3"}, suite.Children)
+	if spec.Name != "Word count" || len(spec.Afters) != 1 || spec.Afters[0].Name != "After Spec" {
+		t.Errorf("Unexpected spec container: %+v", spec)
+	}
+	checkEqual(t, "spec children", []string{"uuid3"}, spec.Children)
+}
+
+func TestAllureExporterWritesEnvironmentAndExecutor(t *testing.T) {
+	res := &SuiteResult{
+		ProjectName: "Gauge Project",
+		Environment: "ci",
+		Build:       &model.BuildInfo{Commit: "0123456789", CI: "Jenkins", BuildNumber: "7", JobURL: "https://ci.example/7"},
+	}
+	dir, cleanUp := exportAllure(t, res)
+	defer cleanUp()
+	want := "Build\\ number=7\nCI=Jenkins\nCommit=0123456789\nEnvironment=ci\nJob\\ URL=https\\://ci.example/7\nProject=Gauge Project\n"
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, allureEnvFile))
+
+	if err != nil {
+		t.Fatalf("Expected %s to be written. Got: %s", allureEnvFile, err.Error())
+	}
+	if string(b) != want {
+		t.Errorf("want: %q, got: %q", want, string(b))
+	}
+	var executor allureExecutor
+	readAllureFile(t, dir, allureExecutorFile, &executor)
+	checkEqual(t, "executor", allureExecutor{Name: "Jenkins", Type: "jenkins", BuildName: "Jenkins #7", BuildURL: "https://ci.example/7", BuildOrder: "7"}, executor)
+}
+
+func TestAllureExporterReplacesPreviousResults(t *testing.T) {
+	dir, cleanUp := exportAllure(t, &SuiteResult{})
+	defer cleanUp()
+	stale := filepath.Join(dir, "stale-result.json")
+	ioutil.WriteFile(stale, []byte("{}"), 0644)
+
+	if err := (&allureExporter{}).Export(&SuiteResult{}, filepath.Dir(dir)); err != nil {
+		t.Fatalf("Expected error to be nil. Got: %s", err.Error())
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed", stale)
+	}
+}
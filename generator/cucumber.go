@@ -0,0 +1,374 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/getgauge/common"
+	"github.com/getgauge/html-report/model"
+)
+
+const (
+	cucumberFormat      = "cucumber"
+	cucumberFile        = "cucumber.json"
+	cucumberStepKeyword = "* "
+)
+
+// The cucumber* types follow the JSON report format of Cucumber, which is read by a number of
+// dashboards and living documentation tools.
+type cucumberFeature struct {
+	URI         string              `json:"uri"`
+	ID          string              `json:"id"`
+	Keyword     string              `json:"keyword"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Line        int                 `json:"line"`
+	Tags        []cucumberTag       `json:"tags"`
+	Elements    []*cucumberElement  `json:"elements"`
+	Metadata    []cucumberMetadatum `json:"metadata,omitempty"`
+}
+
+type cucumberTag struct {
+	Name string `json:"name"`
+	Line int    `json:"line"`
+}
+
+// cucumberMetadatum is a name value pair describing the build the features were executed for.
+type cucumberMetadatum struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cucumberElement struct {
+	ID          string          `json:"id"`
+	Keyword     string          `json:"keyword"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Line        int             `json:"line"`
+	Type        string          `json:"type"`
+	Tags        []cucumberTag   `json:"tags"`
+	Before      []*cucumberHook `json:"before,omitempty"`
+	Steps       []*cucumberStep `json:"steps"`
+	After       []*cucumberHook `json:"after,omitempty"`
+}
+
+type cucumberHook struct {
+	Match      cucumberMatch       `json:"match"`
+	Result     cucumberResult      `json:"result"`
+	Embeddings []cucumberEmbedding `json:"embeddings,omitempty"`
+}
+
+type cucumberStep struct {
+	Keyword    string              `json:"keyword"`
+	Name       string              `json:"name"`
+	Line       int                 `json:"line,omitempty"`
+	Rows       []cucumberRow       `json:"rows,omitempty"`
+	Match      cucumberMatch       `json:"match"`
+	Result     cucumberResult      `json:"result"`
+	Embeddings []cucumberEmbedding `json:"embeddings,omitempty"`
+	Output     []string            `json:"output,omitempty"`
+}
+
+type cucumberRow struct {
+	Cells []string `json:"cells"`
+}
+
+type cucumberMatch struct {
+	Location string `json:"location"`
+}
+
+type cucumberResult struct {
+	Status       string `json:"status"`
+	Duration     int64  `json:"duration"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+type cucumberEmbedding struct {
+	MimeType string `json:"mime_type"`
+	Data     string `json:"data"`
+}
+
+type cucumberExporter struct {
+}
+
+func (e *cucumberExporter) Export(res *SuiteResult, reportsDir string) error {
+	b, err := json.MarshalIndent(toCucumberFeatures(res), "", "  ")
+	if err != nil {
+		return err
+	}
+	p := filepath.Join(reportsDir, cucumberFile)
+	if err := ioutil.WriteFile(p, b, common.NewFilePermissions); err != nil {
+		return err
+	}
+	fmt.Printf("Successfully generated cucumber report to => %s\n", p)
+	return nil
+}
+
+// toCucumberFeatures maps each spec to a feature and each of its scenarios to a scenario element.
+// Scenarios executed for a row of a data table become an element each. Steps and concepts map to
+// steps, a concept carrying the steps it is made of as output lines.
+func toCucumberFeatures(res *SuiteResult) []*cucumberFeature {
+	features := make([]*cucumberFeature, 0)
+	metadata := toCucumberMetadata(res.Build)
+	for _, s := range res.SpecResults {
+		if s == nil {
+			continue
+		}
+		f := &cucumberFeature{
+			URI:         relativeSpecPath(res.ProjectRoot, s.FileName),
+			ID:          toCucumberID(s.SpecHeading),
+			Keyword:     "Specification",
+			Name:        s.SpecHeading,
+			Description: strings.TrimSpace(strings.Join(s.CommentsBeforeDatatable, "\n")),
+			Line:        1,
+			Tags:        toCucumberTags(s.Tags, 1),
+			Elements:    make([]*cucumberElement, 0),
+			Metadata:    metadata,
+		}
+		for _, scn := range s.Scenarios {
+			f.Elements = append(f.Elements, toCucumberElement(f.ID, s, scn))
+		}
+		features = append(features, f)
+	}
+	return features
+}
+
+func toCucumberElement(featureID string, s *spec, scn *scenario) *cucumberElement {
+	line := 0
+	if scn.Span != nil {
+		line = int(scn.Span.Start)
+	}
+	e := &cucumberElement{
+		ID:      featureID + ";" + toCucumberID(scn.Heading),
+		Keyword: "Scenario",
+		Name:    scn.Heading,
+		Line:    line,
+		Type:    "scenario",
+		Tags:    toCucumberTags(append(append([]string{}, s.Tags...), scn.Tags...), line),
+		Steps:   make([]*cucumberStep, 0),
+	}
+	if scn.TableRowIndex >= 0 {
+		e.ID += fmt.Sprintf(";;%d", scn.TableRowIndex+1)
+		e.Keyword = "Scenario Outline"
+		if r := tableRow(s.Datatable, scn.TableRowIndex); r != nil {
+			e.Description = "| " + strings.Join(r.Cells, " | ") + " |"
+		}
+	}
+	for _, h := range append(rowHookFailures(s.BeforeSpecHookFailures, scn.TableRowIndex), scn.BeforeScenarioHookFailure) {
+		if h != nil {
+			e.Before = append(e.Before, toCucumberHook(h))
+		}
+	}
+	for _, items := range [][]item{scn.Contexts, scn.Items, scn.Teardowns} {
+		for _, i := range items {
+			if st := toCucumberStep(i); st != nil {
+				e.Steps = append(e.Steps, st)
+			}
+		}
+	}
+	for _, h := range append([]*hookFailure{scn.AfterScenarioHookFailure}, rowHookFailures(s.AfterSpecHookFailures, scn.TableRowIndex)...) {
+		if h != nil {
+			e.After = append(e.After, toCucumberHook(h))
+		}
+	}
+	return e
+}
+
+// rowHookFailures returns the spec hook failures that apply to the scenarios executed for a data
+// table row, which are those of the row and those that are not specific to a row.
+func rowHookFailures(failures []*hookFailure, rowIndex int) []*hookFailure {
+	var r []*hookFailure
+	for _, h := range failures {
+		if h.TableRowIndex < 0 || int(h.TableRowIndex) == rowIndex {
+			r = append(r, h)
+		}
+	}
+	return r
+}
+
+func toCucumberHook(h *hookFailure) *cucumberHook {
+	return &cucumberHook{
+		Match:      cucumberMatch{Location: h.HookName},
+		Result:     cucumberResult{Status: "failed", ErrorMessage: cucumberError(h.ErrMsg, h.StackTrace)},
+		Embeddings: toCucumberEmbeddings(h.Screenshot),
+	}
+}
+
+func toCucumberStep(i item) *cucumberStep {
+	switch i.Kind {
+	case stepKind:
+		if i.Step == nil {
+			return nil
+		}
+		st := newCucumberStep(i.Step)
+		st.Output = append(st.Output, cucumberMessages(i.Step)...)
+		return st
+	case conceptKind:
+		if i.Concept == nil || i.Concept.ConceptStep == nil {
+			return nil
+		}
+		st := newCucumberStep(i.Concept.ConceptStep)
+		addConceptSteps(st, i.Concept.Items, "")
+		return st
+	}
+	return nil
+}
+
+func newCucumberStep(s *step) *cucumberStep {
+	st := &cucumberStep{
+		Keyword: cucumberStepKeyword,
+		Name:    toStepText(s),
+		Match:   cucumberMatch{Location: toStepText(s)},
+		Result:  cucumberResult{Status: "skipped"},
+	}
+	for _, f := range s.Fragments {
+		if f.FragmentKind == tableFragmentKind && f.Table != nil {
+			st.Rows = append(st.Rows, cucumberRow{Cells: f.Table.Headers})
+			for _, r := range f.Table.Rows {
+				st.Rows = append(st.Rows, cucumberRow{Cells: r.Cells})
+			}
+			break
+		}
+	}
+	if s.Result != nil {
+		st.Result = cucumberResult{Status: cucumberStatus(s.Result.Status), Duration: s.Result.Duration * 1000000}
+		if s.Result.Status == fail || s.Result.Status == recoverableFail {
+			st.Result.ErrorMessage = cucumberError(s.Result.ErrorMessage, s.Result.StackTrace)
+		}
+		st.Embeddings = toCucumberEmbeddings(s.Result.Screenshot)
+	}
+	for _, h := range []*hookFailure{s.BeforeStepHookFailure, s.AfterStepHookFailure} {
+		if h == nil {
+			continue
+		}
+		st.Result.Status = "failed"
+		st.Result.ErrorMessage = strings.TrimSpace(st.Result.ErrorMessage + "\n" + h.HookName + ": " + cucumberError(h.ErrMsg, h.StackTrace))
+		st.Embeddings = append(st.Embeddings, toCucumberEmbeddings(h.Screenshot)...)
+	}
+	return st
+}
+
+// addConceptSteps adds the steps of a concept to its cucumber step as output lines, along with
+// their messages and screenshots. Steps of nested concepts are indented.
+func addConceptSteps(st *cucumberStep, items []item, indent string) {
+	for _, i := range items {
+		switch i.Kind {
+		case stepKind:
+			if i.Step == nil {
+				continue
+			}
+			st.Output = append(st.Output, fmt.Sprintf("%s%s: %s", indent, conceptStepStatus(i.Step), toStepText(i.Step)))
+			for _, m := range cucumberMessages(i.Step) {
+				st.Output = append(st.Output, indent+"  "+m)
+			}
+			if i.Step.Result != nil {
+				st.Embeddings = append(st.Embeddings, toCucumberEmbeddings(i.Step.Result.Screenshot)...)
+			}
+		case conceptKind:
+			if i.Concept == nil || i.Concept.ConceptStep == nil {
+				continue
+			}
+			st.Output = append(st.Output, fmt.Sprintf("%s%s: %s", indent, conceptStepStatus(i.Concept.ConceptStep), toStepText(i.Concept.ConceptStep)))
+			addConceptSteps(st, i.Concept.Items, indent+"  ")
+		}
+	}
+}
+
+func conceptStepStatus(s *step) string {
+	if s.Result == nil {
+		return "skipped"
+	}
+	return cucumberStatus(s.Result.Status)
+}
+
+func cucumberMessages(s *step) []string {
+	if s.Result == nil {
+		return nil
+	}
+	return s.Result.Messages
+}
+
+func cucumberStatus(s status) string {
+	switch s {
+	case pass:
+		return "passed"
+	case fail, recoverableFail:
+		return "failed"
+	default:
+		return "skipped"
+	}
+}
+
+func cucumberError(msg, stackTrace string) string {
+	return strings.TrimSpace(msg + "\n" + stackTrace)
+}
+
+// toCucumberEmbeddings embeds a base64 encoded screenshot, if there is one.
+func toCucumberEmbeddings(screenshot string) []cucumberEmbedding {
+	if screenshot == "" {
+		return nil
+	}
+	mimeType := "image/png"
+	if b, err := base64.StdEncoding.DecodeString(screenshot); err == nil {
+		if t := http.DetectContentType(b); strings.HasPrefix(t, "image/") {
+			mimeType = t
+		}
+	}
+	return []cucumberEmbedding{{MimeType: mimeType, Data: screenshot}}
+}
+
+func toCucumberTags(tags []string, line int) []cucumberTag {
+	r := make([]cucumberTag, 0, len(tags))
+	for _, t := range tags {
+		r = append(r, cucumberTag{Name: "@" + strings.TrimPrefix(t, "@"), Line: line})
+	}
+	return r
+}
+
+func toCucumberMetadata(b *model.BuildInfo) []cucumberMetadatum {
+	if b == nil {
+		return nil
+	}
+	var m []cucumberMetadatum
+	add := func(name, value string) {
+		if value != "" {
+			m = append(m, cucumberMetadatum{Name: name, Value: value})
+		}
+	}
+	add("Commit", b.Commit)
+	add("Branch", b.Branch)
+	if b.Dirty {
+		add("Dirty", "true")
+	}
+	add("CI", b.CI)
+	add("Build number", b.BuildNumber)
+	add("Job URL", b.JobURL)
+	add("Agent", b.AgentName)
+	return m
+}
+
+func toCucumberID(name string) string {
+	return strings.Trim(strings.ToLower(nonAnchorChars.ReplaceAllString(name, "-")), "-")
+}
@@ -0,0 +1,155 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getgauge/html-report/model"
+)
+
+var pngScreenshot = base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\n"))
+
+func cucumberSuiteRes() *SuiteResult {
+	return &SuiteResult{
+		ProjectRoot: "foo",
+		Build:       &model.BuildInfo{Commit: "0123456789", Branch: "main"},
+		SpecResults: []*spec{{
+			SpecHeading: "Word count",
+			FileName:    filepath.Join("foo", "specs", "words.spec"),
+			Tags:        []string{"words"},
+			Datatable:   &table{Headers: []string{"Word"}, Rows: []*row{{Cells: []string{"Gauge"}}}},
+			Scenarios: []*scenario{{
+				Heading:         "Count words",
+				Tags:            []string{"fast"},
+				ExecutionStatus: fail,
+				TableRowIndex:   0,
+				Span:            &model.Span{Start: 4, End: 8},
+				Items: []item{
+					{Kind: stepKind, Step: &step{
+						Fragments: []*fragment{{FragmentKind: textFragmentKind, Text: "Open app"}},
+						Result:    &result{Status: pass, Duration: 12, Messages: []string{"opened"}},
+					}},
+					{Kind: conceptKind, Concept: &concept{
+						ConceptStep: &step{
+							Fragments: []*fragment{{FragmentKind: textFragmentKind, Text: "Count"}},
+							Result:    &result{Status: fail, Duration: 30, ErrorMessage: "wrong count", StackTrace: "at count.go:1"},
+						},
+						Items: []item{
+							{Kind: stepKind, Step: &step{
+								Fragments: []*fragment{{FragmentKind: textFragmentKind, Text: "Inner"}},
+								Result:    &result{Status: fail, Duration: 30, Screenshot: pngScreenshot, Messages: []string{"counted 2"}},
+							}},
+						},
+					}},
+					{Kind: commentKind, Comment: &comment{Text: "a comment"}},
+				},
+				AfterScenarioHookFailure: &hookFailure{HookName: "After Scenario", ErrMsg: "cleanup failed", TableRowIndex: -1},
+			}},
+		}},
+	}
+}
+
+func TestToCucumberFeatures(t *testing.T) {
+	want := []*cucumberFeature{{
+		URI:      "specs/words.spec",
+		ID:       "word-count",
+		Keyword:  "Specification",
+		Name:     "Word count",
+		Line:     1,
+		Tags:     []cucumberTag{{Name: "@words", Line: 1}},
+		Metadata: []cucumberMetadatum{{Name: "Commit", Value: "0123456789"}, {Name: "Branch", Value: "main"}},
+		Elements: []*cucumberElement{{
+			ID:          "word-count;count-words;;1",
+			Keyword:     "Scenario Outline",
+			Name:        "Count words",
+			Description: "| Gauge |",
+			Line:        4,
+			Type:        "scenario",
+			Tags:        []cucumberTag{{Name: "@words", Line: 4}, {Name: "@fast", Line: 4}},
+			Steps: []*cucumberStep{
+				{
+					Keyword: "* ",
+					Name:    "Open app",
+					Match:   cucumberMatch{Location: "Open app"},
+					Result:  cucumberResult{Status: "passed", Duration: 12000000},
+					Output:  []string{"opened"},
+				},
+				{
+					Keyword:    "* ",
+					Name:       "Count",
+					Match:      cucumberMatch{Location: "Count"},
+					Result:     cucumberResult{Status: "failed", Duration: 30000000, ErrorMessage: "wrong count\nat count.go:1"},
+					Embeddings: []cucumberEmbedding{{MimeType: "image/png", Data: pngScreenshot}},
+					Output:     []string{"failed: Inner", "  counted 2"},
+				},
+			},
+			After: []*cucumberHook{{
+				Match:  cucumberMatch{Location: "After Scenario"},
+				Result: cucumberResult{Status: "failed", ErrorMessage: "cleanup failed"},
+			}},
+		}},
+	}}
+
+	got := toCucumberFeatures(cucumberSuiteRes())
+
+	checkEqual(t, "", want, got)
+}
+
+func TestToCucumberFeaturesAddsSpecHookFailuresOfTheRow(t *testing.T) {
+	res := cucumberSuiteRes()
+	res.SpecResults[0].BeforeSpecHookFailures = []*hookFailure{
+		{HookName: "Before Spec", ErrMsg: "row 1", TableRowIndex: 0},
+		{HookName: "Before Spec", ErrMsg: "row 2", TableRowIndex: 1},
+	}
+
+	got := toCucumberFeatures(res)[0].Elements[0].Before
+
+	if len(got) != 1 || got[0].Result.ErrorMessage != "row 1" {
+		t.Errorf("want only the hook failure of row 1, got: %v", got)
+	}
+}
+
+func TestCucumberExporterWritesFeatures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cucumber")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := (&cucumberExporter{}).Export(cucumberSuiteRes(), dir); err != nil {
+		t.Fatalf("Expected error to be nil. Got: %s", err.Error())
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, cucumberFile))
+	if err != nil {
+		t.Fatalf("Expected %s to be written. Got: %s", cucumberFile, err.Error())
+	}
+	var features []map[string]interface{}
+	if err := json.Unmarshal(b, &features); err != nil {
+		t.Fatalf("Expected valid json. Got: %s", err.Error())
+	}
+	if len(features) != 1 || features[0]["name"] != "Word count" {
+		t.Errorf("Unexpected features: %v", features)
+	}
+}
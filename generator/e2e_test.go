@@ -54,7 +54,7 @@ func TestEndToEndHTMLGenerationWhenBeforeSuiteFails(t *testing.T) {
 }
 
 func TestEndToEndHTMLGeneration(t *testing.T) {
-	expectedFiles := []string{"index.html", "passing_specification_1.html", "failing_specification_1.html", "skipped_specification.html", "js/search_index.js"}
+	expectedFiles := []string{"index.html", "passing_specification_1.html", "failing_specification_1.html", "skipped_specification.html", "skipped.html", "js/search_index.js", "js/search/0.js"}
 	reportDir := filepath.Join("_testdata", "e2e")
 
 	r := ToSuiteResult("", suiteRes3)
@@ -69,7 +69,7 @@ func TestEndToEndHTMLGeneration(t *testing.T) {
 }
 
 func TestEndToEndHTMLGenerationForThemeWithRelativePath(t *testing.T) {
-	expectedFiles := []string{"index.html", "passing_specification_1.html", "failing_specification_1.html", "skipped_specification.html", "js/search_index.js"}
+	expectedFiles := []string{"index.html", "passing_specification_1.html", "failing_specification_1.html", "skipped_specification.html", "skipped.html", "js/search_index.js", "js/search/0.js"}
 	reportDir := filepath.Join("_testdata", "e2e")
 	defaultThemePath := filepath.Join("..", "themes", "default")
 
@@ -85,7 +85,7 @@ func TestEndToEndHTMLGenerationForThemeWithRelativePath(t *testing.T) {
 }
 
 func TestEndToEndHTMLGenerationForCustomTheme(t *testing.T) {
-	expectedFiles := []string{"index.html", "passing_specification_1.html", "failing_specification_1.html", "skipped_specification.html", "js/search_index.js"}
+	expectedFiles := []string{"index.html", "passing_specification_1.html", "failing_specification_1.html", "skipped_specification.html", "skipped.html", "js/search_index.js", "js/search/0.js"}
 	reportDir := filepath.Join("_testdata", "e2e")
 	defaultThemePath := filepath.Join("_testdata", "dummyReportTheme")
 
@@ -109,6 +109,8 @@ func TestEndToEndHTMLGenerationForNestedSpecs(t *testing.T) {
 		filepath.Join("nested", "nested_specification.html"),
 		filepath.Join("nested", "index.html"),
 		"js/search_index.js",
+		"js/search/0.js",
+		"js/search/1.js",
 	}
 	reportDir := filepath.Join("_testdata", "e2e")
 
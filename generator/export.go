@@ -0,0 +1,176 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/getgauge/common"
+	"github.com/getgauge/html-report/env"
+	"github.com/getgauge/html-report/theme"
+)
+
+const (
+	htmlFormat     = "html"
+	jsonFormat     = "json"
+	jsonResultFile = "result.json"
+)
+
+// Exporter writes a converted SuiteResult to reportsDir in a specific output format.
+type Exporter interface {
+	Export(res *SuiteResult, reportsDir string) error
+}
+
+// themedExporter is implemented by exporters that render the report with a theme, which can be
+// overridden for a single report.
+type themedExporter interface {
+	withTheme(themePath string) Exporter
+}
+
+// exporters holds the registered exporters by format. Reports can be generated
+// while serving or watching, hence the lock.
+var (
+	exportersMu sync.RWMutex
+	exporters   = make(map[string]Exporter)
+)
+
+func init() {
+	RegisterExporter(htmlFormat, &htmlExporter{})
+	RegisterExporter(jsonFormat, &jsonExporter{})
+	RegisterExporter(markdownFormat, &markdownExporter{})
+	RegisterExporter(cucumberFormat, &cucumberExporter{})
+	RegisterExporter(allureFormat, &allureExporter{})
+	RegisterExporter(sarifFormat, &sarifExporter{})
+	RegisterExporter(metricsFormat, &metricsExporter{})
+}
+
+// RegisterExporter makes an Exporter available under the given format name.
+// Registering a format twice replaces the previous exporter.
+func RegisterExporter(format string, e Exporter) {
+	exportersMu.Lock()
+	defer exportersMu.Unlock()
+	exporters[format] = e
+}
+
+func registeredExporter(format string) (Exporter, bool) {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+	e, ok := exporters[format]
+	return e, ok
+}
+
+// Formats returns the names of all registered exporters.
+func Formats() []string {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+	formats := make([]string, 0, len(exporters))
+	for f := range exporters {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Export runs the exporter registered for each of the given formats.
+// A failing exporter does not stop the remaining ones; the errors of all
+// failed exporters are returned.
+func Export(res *SuiteResult, reportsDir string, formats []string) []error {
+	return ExportWithTheme(res, reportsDir, "", formats)
+}
+
+// ExportWithTheme is like Export, but exporters that render a theme, like the html exporter,
+// use the theme at themePath instead of the one they are registered with. An empty themePath
+// keeps the registered theme.
+func ExportWithTheme(res *SuiteResult, reportsDir, themePath string, formats []string) []error {
+	var errs []error
+	for _, f := range formats {
+		e, ok := registeredExporter(f)
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown report format '%s'", f))
+			continue
+		}
+		if t, ok := e.(themedExporter); ok && themePath != "" {
+			e = t.withTheme(themePath)
+		}
+		if err := runExporter(e, res, reportsDir); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", f, err.Error()))
+		}
+	}
+	return errs
+}
+
+func runExporter(e Exporter, res *SuiteResult, reportsDir string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return e.Export(res, reportsDir)
+}
+
+// htmlExporter renders the html report with the theme at themePath, or with the theme of the
+// plugin installation if it is empty.
+type htmlExporter struct {
+	themePath string
+}
+
+// NewHTMLExporter returns the built-in exporter that renders the html report using the given theme.
+func NewHTMLExporter(themePath string) Exporter {
+	return &htmlExporter{themePath: themePath}
+}
+
+func (e *htmlExporter) withTheme(themePath string) Exporter {
+	return &htmlExporter{themePath: themePath}
+}
+
+func (e *htmlExporter) Export(res *SuiteResult, reportsDir string) error {
+	themePath := e.themePath
+	if themePath == "" {
+		exeDir, _ := env.GetCurrentExecutableDir()
+		themePath = theme.GetThemePath(filepath.Dir(exeDir))
+	}
+	if err := GenerateReports(res, reportsDir, themePath); err != nil {
+		return err
+	}
+	if err := theme.CopyReportTemplateFiles(themePath, reportsDir); err != nil {
+		return fmt.Errorf("error copying template directory: %s", err.Error())
+	}
+	fmt.Printf("Successfully generated html-report to => %s\n", filepath.Join(reportsDir, "index.html"))
+	return nil
+}
+
+type jsonExporter struct {
+}
+
+func (e *jsonExporter) Export(res *SuiteResult, reportsDir string) error {
+	b, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	p := filepath.Join(reportsDir, jsonResultFile)
+	if err := ioutil.WriteFile(p, b, common.NewFilePermissions); err != nil {
+		return err
+	}
+	fmt.Printf("Successfully generated json report to => %s\n", p)
+	return nil
+}
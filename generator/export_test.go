@@ -0,0 +1,148 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+type fakeExporter struct {
+	called bool
+	err    error
+	panics bool
+}
+
+func (e *fakeExporter) Export(res *SuiteResult, reportsDir string) error {
+	e.called = true
+	if e.panics {
+		panic("exporter crashed")
+	}
+	return e.err
+}
+
+func TestExportRunsAllRequestedExporters(t *testing.T) {
+	first, second := &fakeExporter{}, &fakeExporter{}
+	RegisterExporter("first", first)
+	RegisterExporter("second", second)
+	defer delete(exporters, "first")
+	defer delete(exporters, "second")
+
+	errs := Export(&SuiteResult{}, "", []string{"first", "second"})
+
+	if len(errs) != 0 {
+		t.Errorf("Expected no errors, got %v", errs)
+	}
+	if !first.called || !second.called {
+		t.Errorf("Expected both exporters to be called")
+	}
+}
+
+func TestExportIsolatesExporterFailures(t *testing.T) {
+	failing := &fakeExporter{err: errors.New("disk full")}
+	crashing := &fakeExporter{panics: true}
+	passing := &fakeExporter{}
+	RegisterExporter("failing", failing)
+	RegisterExporter("crashing", crashing)
+	RegisterExporter("passing", passing)
+	defer delete(exporters, "failing")
+	defer delete(exporters, "crashing")
+	defer delete(exporters, "passing")
+
+	errs := Export(&SuiteResult{}, "", []string{"failing", "crashing", "unknown", "passing"})
+
+	if len(errs) != 3 {
+		t.Fatalf("Expected 3 errors, got %d: %v", len(errs), errs)
+	}
+	want := []string{"failing: disk full", "crashing: exporter crashed", "unknown report format 'unknown'"}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("want: %q, got: %q", want[i], err.Error())
+		}
+	}
+	if !passing.called {
+		t.Errorf("Expected exporter after failures to be called")
+	}
+}
+
+func TestFormatsListsHTML(t *testing.T) {
+	want := []string{allureFormat, cucumberFormat, htmlFormat, jsonFormat, markdownFormat, metricsFormat, sarifFormat}
+	sort.Strings(want)
+
+	checkEqual(t, "formats", want, Formats())
+}
+
+func TestExportWithThemeKeepsRegisteredHTMLExporter(t *testing.T) {
+	registered, _ := registeredExporter(htmlFormat)
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	errs := ExportWithTheme(&SuiteResult{}, dir, filepath.Join(dir, "missing-theme"), []string{htmlFormat})
+
+	if len(errs) != 1 {
+		t.Errorf("Expected the html report to fail with the missing theme, got %v", errs)
+	}
+	e, _ := registeredExporter(htmlFormat)
+	if e != registered || e.(*htmlExporter).themePath != "" {
+		t.Errorf("Expected the registered html exporter to keep the default theme, got %+v", e)
+	}
+}
+
+func TestGenerateReportReturnsErrorsOfFailedFormats(t *testing.T) {
+	RegisterExporter("failing", &fakeExporter{err: errors.New("disk full")})
+	defer delete(exporters, "failing")
+
+	err := GenerateReport(&SuiteResult{}, "", "", []string{"failing", "unknown"})
+
+	want := "failed to generate reports: failing: disk full; unknown report format 'unknown'"
+	if err == nil || err.Error() != want {
+		t.Errorf("want: %q, got: %v", want, err)
+	}
+}
+
+func TestJSONExporterWritesSuiteResult(t *testing.T) {
+	reportDir, err := ioutil.TempDir("", "json-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(reportDir)
+	res := &SuiteResult{ProjectName: "foo", ExecutionStatus: pass, SpecResults: []*spec{{SpecHeading: "bar"}}}
+
+	errs := Export(res, reportDir, []string{jsonFormat})
+
+	if len(errs) != 0 {
+		t.Fatalf("Expected no errors, got %v", errs)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(reportDir, jsonResultFile))
+	if err != nil {
+		t.Fatalf("Expected %s to be written: %s", jsonResultFile, err.Error())
+	}
+	got := &SuiteResult{}
+	if err := json.Unmarshal(b, got); err != nil {
+		t.Fatalf("Expected valid json: %s", err.Error())
+	}
+	checkEqual(t, "json export", res, got)
+}
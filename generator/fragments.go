@@ -17,21 +17,15 @@
 
 package generator
 
+import "github.com/getgauge/html-report/model"
+
 const (
-	textFragmentKind fragmentKind = iota
-	staticFragmentKind
-	dynamicFragmentKind
-	specialStringFragmentKind
-	specialTableFragmentKind
-	tableFragmentKind
+	textFragmentKind          = model.TextFragmentKind
+	staticFragmentKind        = model.StaticFragmentKind
+	dynamicFragmentKind       = model.DynamicFragmentKind
+	specialStringFragmentKind = model.SpecialStringFragmentKind
+	specialTableFragmentKind  = model.SpecialTableFragmentKind
+	tableFragmentKind         = model.TableFragmentKind
 )
 
-type fragmentKind int
-
-type fragment struct {
-	FragmentKind fragmentKind
-	Text         string
-	Name         string
-	Table        *table
-	FileName     string
-}
+type fragment = model.Fragment
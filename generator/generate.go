@@ -17,12 +17,13 @@
 package generator
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -33,16 +34,17 @@ import (
 
 	"github.com/getgauge/common"
 	"github.com/getgauge/html-report/env"
-	"github.com/getgauge/html-report/theme"
+	"github.com/getgauge/html-report/model"
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/russross/blackfriday"
 )
 
 type summary struct {
-	Total   int
-	Failed  int
-	Passed  int
-	Skipped int
+	Total       int
+	Failed      int
+	Passed      int
+	Skipped     int
+	Recoverable int
 }
 
 type overview struct {
@@ -54,194 +56,99 @@ type overview struct {
 	Timestamp     string
 	Summary       *summary
 	BasePath      string
+	Build         *model.BuildInfo
+	ErrorTypes    []*errorTypeCount
+	Skipped       int
 }
 
+// errorTypeCount is the number of failed steps with an error type.
+type errorTypeCount struct {
+	Type  errorType
+	Count int
+}
+
+// specsMeta is a spec as listed in the sidebar. Order is the position of the spec in the suite
+// result, the order Gauge executed the specs in, which the sidebar can be sorted back to.
 type specsMeta struct {
 	SpecName      string
 	ExecutionTime string
+	Duration      int64
+	Order         int
 	Failed        bool
 	Skipped       bool
 	Tags          []string
 	ReportFile    string
+	ErrorTypes    string
 }
 
 type sidebar struct {
-	IsBeforeHookFailure bool
-	Specs               []*specsMeta
+	IsBeforeHookFailure     bool
+	HasVerificationFailures bool
+	Specs                   []*specsMeta
 }
 
 type specHeader struct {
 	SpecName      string
 	ExecutionTime string
 	FileName      string
+	SourceURL     string
 	Tags          []string
 	Summary       *summary
 }
 
-type errorType string
-type tokenKind string
-type status string
-
-type buildError struct {
-	ErrorType  errorType
-	FileName   string
-	LineNumber int
-	Message    string
-}
+// The generator works on the types of the model package. These aliases keep
+// the unexported names that the rest of the package and its tests use.
+type (
+	errorType   = model.ErrorType
+	tokenKind   = model.TokenKind
+	status      = model.Status
+	buildError  = model.BuildError
+	spec        = model.Spec
+	scenario    = model.Scenario
+	step        = model.Step
+	result      = model.Result
+	hookFailure = model.HookFailure
+	concept     = model.Concept
+	table       = model.Table
+	row         = model.Row
+	item        = model.Item
+	comment     = model.Comment
+)
 
 // SuiteResult holds the aggregated execution information for a run
-type SuiteResult struct {
-	ProjectName            string       `json:"ProjectName"`
-	Timestamp              string       `json:"Timestamp"`
-	SuccessRate            float32      `json:"SuccessRate"`
-	Environment            string       `json:"Environment"`
-	Tags                   string       `json:"Tags"`
-	ExecutionTime          int64        `json:"ExecutionTime"`
-	ExecutionStatus        status       `json:"ExecutionStatus"`
-	SpecResults            []*spec      `json:"SpecResults"`
-	BeforeSuiteHookFailure *hookFailure `json:"BeforeSuiteHookFailure"`
-	AfterSuiteHookFailure  *hookFailure `json:"AfterSuiteHookFailure"`
-	PassedSpecsCount       int          `json:"PassedSpecsCount"`
-	FailedSpecsCount       int          `json:"FailedSpecsCount"`
-	SkippedSpecsCount      int          `json:"SkippedSpecsCount"`
-	BasePath               string       `json:"BasePath"`
-}
-
-type spec struct {
-	CommentsBeforeDatatable []string       `json:"CommentsBeforeDatatable"`
-	CommentsAfterDatatable  []string       `json:"CommentsAfterDatatable"`
-	SpecHeading             string         `json:"SpecHeading"`
-	FileName                string         `json:"FileName"`
-	Tags                    []string       `json:"Tags"`
-	ExecutionTime           int64          `json:"ExecutionTime"`
-	ExecutionStatus         status         `json:"ExecutionStatus"`
-	Scenarios               []*scenario    `json:"Scenarios"`
-	IsTableDriven           bool           `json:"IsTableDriven"`
-	Datatable               *table         `json:"Datatable"`
-	BeforeSpecHookFailures  []*hookFailure `json:"BeforeSpecHookFailures"`
-	AfterSpecHookFailures   []*hookFailure `json:"AfterSpecHookFailures"`
-	PassedScenarioCount     int            `json:"PassedScenarioCount"`
-	FailedScenarioCount     int            `json:"FailedScenarioCount"`
-	SkippedScenarioCount    int            `json:"SkippedScenarioCount"`
-	Errors                  []buildError   `json:"Errors"`
-}
-
-type scenario struct {
-	Heading                   string       `json:"Heading"`
-	Tags                      []string     `json:"Tags"`
-	ExecutionTime             string       `json:"ExecutionTime"`
-	ExecutionStatus           status       `json:"ExecutionStatus"`
-	Contexts                  []item       `json:"Contexts"`
-	Teardowns                 []item       `json:"Teardowns"`
-	Items                     []item       `json:"Items"`
-	BeforeScenarioHookFailure *hookFailure `json:"BeforeScenarioHookFailure"`
-	AfterScenarioHookFailure  *hookFailure `json:"AfterScenarioHookFailure"`
-	SkipErrors                []string     `json:"SkipErrors"`
-	TableRowIndex             int          `json:"TableRowIndex"`
-}
-
-type step struct {
-	Fragments             []*fragment  `json:"Fragments"`
-	ItemType              tokenKind    `json:"ItemType"`
-	StepText              string       `json:"StepText"`
-	Table                 *table       `json:"Table"`
-	BeforeStepHookFailure *hookFailure `json:"BeforeStepHookFailure"`
-	AfterStepHookFailure  *hookFailure `json:"AfterStepHookFailure"`
-	Result                *result      `json:"Result"`
-}
-
-func (s *step) Kind() tokenKind {
-	return stepKind
-}
-
-type result struct {
-	Status        status    `json:"Status"`
-	StackTrace    string    `json:"StackTrace"`
-	Screenshot    string    `json:"Screenshot"`
-	ErrorMessage  string    `json:"ErrorMessage"`
-	ExecutionTime string    `json:"ExecutionTime"`
-	SkippedReason string    `json:"SkippedReason"`
-	Messages      []string  `json:"Messages"`
-	ErrorType     errorType `json:"ErrorType"`
-}
-
-type hookFailure struct {
-	HookName      string `json:"HookName"`
-	ErrMsg        string `json:"ErrMsg"`
-	Screenshot    string `json:"Screenshot"`
-	StackTrace    string `json:"StackTrace"`
-	TableRowIndex int32  `json:"TableRowIndex"`
-}
-
-type concept struct {
-	ItemType    tokenKind `json:"ItemType"`
-	ConceptStep *step     `json:"ConceptStep"`
-	Items       []item    `json:"Items"`
-	Result      result    `json:"Result"`
-}
-
-func (s *concept) Kind() tokenKind {
-	return conceptKind
-}
-
-type table struct {
-	Headers []string `json:"Headers"`
-	Rows    []*row   `json:"Rows"`
-}
-
-type row struct {
-	Cells  []string `json:"Cells"`
-	Result status   `json:"Status"`
-}
-
-func (e buildError) Error() string {
-	if e.isParseError() {
-		return "[Parse Error] " + e.Message
-	}
-	return "[Validation Error] " + e.Message
-}
-
-func (e buildError) isParseError() bool {
-	return e.ErrorType == parseErrorType
-}
-
-type item struct {
-	Kind    tokenKind
-	Step    *step
-	Concept *concept
-	Comment *comment
-}
-
-type comment struct {
-	Text string
-}
-
-func (c *comment) Kind() tokenKind {
-	return commentKind
-}
+type SuiteResult = model.SuiteResult
 
 type searchIndex struct {
-	Tags  map[string][]string `json:"Tags"`
-	Specs map[string][]string `json:"Specs"`
+	Tags   map[string][]string        `json:"Tags"`
+	Specs  map[string][]string        `json:"Specs"`
+	Shards map[string]*searchShardRef `json:"Shards"`
 }
 
 const (
-	pass                  status    = "pass"
-	fail                  status    = "fail"
-	skip                  status    = "skip"
-	notExecuted           status    = "not executed"
-	stepKind              tokenKind = "step"
-	conceptKind           tokenKind = "concept"
-	commentKind           tokenKind = "comment"
-	assertionErrorType    errorType = "assertion"
-	parseErrorType        errorType = "parse"
-	verificationErrorType errorType = "verification"
-	validationErrorType   errorType = "validation"
+	pass                  = model.Pass
+	fail                  = model.Fail
+	skip                  = model.Skip
+	notExecuted           = model.NotExecuted
+	recoverableFail       = model.RecoverableFail
+	stepKind              = model.StepKind
+	conceptKind           = model.ConceptKind
+	commentKind           = model.CommentKind
+	assertionErrorType    = model.AssertionErrorType
+	parseErrorType        = model.ParseErrorType
+	verificationErrorType = model.VerificationErrorType
+	validationErrorType   = model.ValidationErrorType
 )
 
 var parsedTemplates *template.Template
 
-func readTemplates(themePath string) {
+// templateErr is the first error of executing a template since the report generation started.
+// Pages are rendered concurrently, hence the lock.
+var (
+	templateErrMu sync.Mutex
+	templateErr   error
+)
+
+func readTemplates(themePath string) error {
 	var encodeNewLine = func(s string) string {
 		return strings.Replace(s, "\n", "<br/>", -1)
 	}
@@ -257,46 +164,83 @@ func readTemplates(themePath string) {
 	}
 
 	var funcs = template.FuncMap{
-		"parseMarkdown":       parseMarkdown,
-		"sanitize":            sanitizeHTML,
-		"escapeHTML":          template.HTMLEscapeString,
-		"encodeNewLine":       encodeNewLine,
-		"containsParseErrors": containsParseErrors,
-		"toSpecHeader":        toSpecHeader,
-		"toSidebar":           toSidebar,
-		"toOverview":          toOverview,
-		"toPath":              path.Join,
+		"parseMarkdown":        parseMarkdown,
+		"sanitize":             sanitizeHTML,
+		"escapeHTML":           template.HTMLEscapeString,
+		"encodeNewLine":        encodeNewLine,
+		"containsParseErrors":  containsParseErrors,
+		"toSpecHeader":         toSpecHeader,
+		"toSidebar":            toSidebar,
+		"toOverview":           toOverview,
+		"toPath":               path.Join,
+		"toSourceView":         toSourceView,
+		"toScenarioErrorTypes": toScenarioErrorTypes,
+		"toHTTPURL":            toHTTPURL,
+		"toStatusClass":        toStatusClass,
+		"join":                 strings.Join,
 	}
-	f, err := ioutil.ReadFile(filepath.Join(getAbsThemePath(themePath), "views", "partials.tmpl"))
+	f, err := ioutil.ReadFile(filepath.Join(themePath, "views", "partials.tmpl"))
 	if err != nil {
-		log.Fatalf(err.Error())
+		return err
 	}
-	parsedTemplates, err = template.New("Reports").Funcs(funcs).Parse(string(f))
+	t, err := template.New("Reports").Funcs(funcs).Parse(string(f))
 	if err != nil {
-		log.Fatalf(err.Error())
+		return err
+	}
+	parsedTemplates = t
+	return nil
+}
+
+// toHTTPURL returns s if it is an http or https url, so that urls taken from the environment
+// cannot run scripts when used as a link. Returns an empty string otherwise.
+func toHTTPURL(s string) string {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
 	}
+	return u.String()
+}
+
+// toStatusClass returns a status as a single css class, as statuses like "recoverable fail" hold spaces.
+func toStatusClass(s status) string {
+	return strings.Replace(string(s), " ", "-", -1)
 }
 
-func getAbsThemePath(themePath string) string {
+// getAbsThemePath resolves a theme path relative to the project root.
+func getAbsThemePath(root, themePath string) string {
 	if filepath.IsAbs(themePath) {
 		return themePath
 	}
-	return filepath.Join(projectRoot, themePath)
+	return filepath.Join(root, themePath)
 }
 
 func execTemplate(tmplName string, w io.Writer, data interface{}) {
 	err := parsedTemplates.ExecuteTemplate(w, tmplName, data)
 	if err != nil {
-		log.Fatalf(err.Error())
+		templateErrMu.Lock()
+		if templateErr == nil {
+			templateErr = err
+		}
+		templateErrMu.Unlock()
 	}
 }
 
-// ProjectRoot is root dir of current project
-var projectRoot string
+// takeTemplateError returns the first error of executing a template and clears it.
+func takeTemplateError() error {
+	templateErrMu.Lock()
+	defer templateErrMu.Unlock()
+	err := templateErr
+	templateErr = nil
+	return err
+}
 
-// GenerateReports generates HTML report in the given report dir location
+// GenerateReports generates HTML report in the given report dir location. Errors in the templates
+// of the theme are returned once all pages are written.
 func GenerateReports(res *SuiteResult, reportsDir, themePath string) error {
-	readTemplates(themePath)
+	if err := readTemplates(getAbsThemePath(res.ProjectRoot, themePath)); err != nil {
+		return err
+	}
+	takeTemplateError()
 	f, err := os.Create(filepath.Join(reportsDir, "index.html"))
 	if err != nil {
 		return err
@@ -306,43 +250,48 @@ func GenerateReports(res *SuiteResult, reportsDir, themePath string) error {
 		execTemplate("indexPageFailure", f, res)
 	} else {
 		var wg sync.WaitGroup
+		var indexErr error
 		wg.Add(1)
 		res.BasePath = ""
 		go generateIndexPage(res, f, &wg)
 		if env.ShouldUseNestedSpecs() {
-			go generateIndexPages(res, reportsDir, &wg)
-		}
-		specRes := res.SpecResults
-		for _, r := range specRes {
-			relPath, _ := filepath.Rel(projectRoot, r.FileName)
-			env.CreateDirectory(filepath.Join(reportsDir, filepath.Dir(relPath)))
-			sf, err := os.Create(filepath.Join(reportsDir, toHTMLFileName(r.FileName, projectRoot)))
-			if err != nil {
-				return err
-			}
-			defer sf.Close()
 			wg.Add(1)
-			go generateSpecPage(res, r, sf, &wg)
+			go func() {
+				defer wg.Done()
+				indexErr = generateIndexPages(res, reportsDir)
+			}()
 		}
+		err = generateSpecPages(res, reportsDir, env.GetConcurrency())
 		wg.Wait()
+		if err != nil {
+			return err
+		}
+		if indexErr != nil {
+			return indexErr
+		}
+		if err = generateSkippedPage(res, reportsDir); err != nil {
+			return err
+		}
 	}
 	err = generateSearchIndex(res, reportsDir)
 	if err != nil {
 		return err
 	}
-	return nil
+	return takeTemplateError()
 }
 
-func GenerateReport(res *SuiteResult, reportDir, themePath string) {
-	err := GenerateReports(res, reportDir, themePath)
-	if err != nil {
-		log.Fatalf("Failed to generate reports: %s\n", err.Error())
+// GenerateReport writes the report in each of the given formats. The html format is rendered with the theme at themePath.
+// The error lists the formats that failed; the report is still written in the others.
+func GenerateReport(res *SuiteResult, reportDir, themePath string, formats []string) error {
+	errs := ExportWithTheme(res, reportDir, themePath, formats)
+	if len(errs) == 0 {
+		return nil
 	}
-	err = theme.CopyReportTemplateFiles(themePath, reportDir)
-	if err != nil {
-		log.Fatalf("Error copying template directory :%s\n", err.Error())
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
 	}
-	fmt.Printf("Successfully generated html-report to => %s\n", filepath.Join(reportDir, "index.html"))
+	return fmt.Errorf("failed to generate reports: %s", strings.Join(msgs, "; "))
 }
 
 func newSearchIndex() *searchIndex {
@@ -372,7 +321,7 @@ func (i *searchIndex) hasSpec(specHeading string, specFileName string) bool {
 
 func containsParseErrors(errors []buildError) bool {
 	for _, e := range errors {
-		if e.isParseError() {
+		if e.IsParseError() {
 			return true
 		}
 	}
@@ -388,7 +337,7 @@ func generateSearchIndex(suiteRes *SuiteResult, reportsDir string) error {
 	defer f.Close()
 	index := newSearchIndex()
 	for _, r := range suiteRes.SpecResults {
-		specFileName := toHTMLFileName(r.FileName, projectRoot)
+		specFileName := toHTMLFileName(r.FileName, suiteRes.ProjectRoot)
 		for _, t := range r.Tags {
 			if !index.hasValueForTag(t, specFileName) {
 				index.Tags[t] = append(index.Tags[t], specFileName)
@@ -406,6 +355,10 @@ func generateSearchIndex(suiteRes *SuiteResult, reportsDir string) error {
 			index.Specs[specHeading] = append(index.Specs[specHeading], specFileName)
 		}
 	}
+	index.Shards, err = generateSearchShards(suiteRes, reportsDir)
+	if err != nil {
+		return err
+	}
 	s, err := json.Marshal(index)
 	if err != nil {
 		return err
@@ -419,12 +372,12 @@ func generateIndexPage(suiteRes *SuiteResult, w io.Writer, wg *sync.WaitGroup) {
 	execTemplate("indexPage", w, suiteRes)
 }
 
-func generateIndexPages(suiteRes *SuiteResult, reportsDir string, wg *sync.WaitGroup) {
+func generateIndexPages(suiteRes *SuiteResult, reportsDir string) error {
 	dirs := make(map[string]int)
 	for _, s := range suiteRes.SpecResults {
-		p, err := filepath.Rel(projectRoot, filepath.Dir(s.FileName))
+		p, err := filepath.Rel(suiteRes.ProjectRoot, filepath.Dir(s.FileName))
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 		childDirs := filepath.SplitList(p)
 		basePath := ""
@@ -440,22 +393,96 @@ func generateIndexPages(suiteRes *SuiteResult, reportsDir string, wg *sync.WaitG
 	for d := range dirs {
 		dirPath := filepath.Join(reportsDir, d)
 		os.MkdirAll(dirPath, common.NewDirectoryPermissions)
-		p := filepath.Join(dirPath, "index.html")
-		f, err := os.Create(p)
+		err := writeFile(filepath.Join(dirPath, "index.html"), func(w io.Writer) {
+			execTemplate("indexPage", w, toNestedSuiteResult(d, suiteRes))
+		})
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
-		defer f.Close()
+	}
+	return nil
+}
+
+// generateSpecPages renders the spec pages using at most `workers` goroutines.
+// A spec page file is only created once a worker picks it up, so no more than
+// `workers` files are open at any time.
+func generateSpecPages(suiteRes *SuiteResult, reportsDir string, workers int) error {
+	specs := suiteRes.SpecResults
+	if workers > len(specs) {
+		workers = len(specs)
+	}
+	jobs := make(chan *spec)
+	errs := make(chan error, len(specs))
+	p := newProgress(len(specs))
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		res := toNestedSuiteResult(d, suiteRes)
-		generateIndexPage(res, f, wg)
+		go func() {
+			defer wg.Done()
+			for s := range jobs {
+				if err := generateSpecPageFile(suiteRes, s, reportsDir); err != nil {
+					errs <- err
+				}
+				p.done()
+			}
+		}()
+	}
+	for _, s := range specs {
+		jobs <- s
 	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+	return <-errs
 }
 
-func generateSpecPage(suiteRes *SuiteResult, specRes *spec, w io.Writer, wg *sync.WaitGroup) {
-	defer wg.Done()
+func generateSpecPageFile(suiteRes *SuiteResult, specRes *spec, reportsDir string) error {
+	page := toHTMLFileName(specRes.FileName, suiteRes.ProjectRoot)
+	env.CreateDirectory(filepath.Join(reportsDir, filepath.Dir(page)))
+	return writeFile(filepath.Join(reportsDir, page), func(w io.Writer) {
+		generateSpecPage(suiteRes, specRes, w)
+	})
+}
+
+func writeFile(p string, write func(w io.Writer)) error {
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	write(w)
+	return w.Flush()
+}
+
+func generateSpecPage(suiteRes *SuiteResult, specRes *spec, w io.Writer) {
 	execTemplate("specPage", w, struct {
 		SuiteRes *SuiteResult
 		SpecRes  *spec
 	}{suiteRes, specRes})
 }
+
+// progress prints the number of rendered spec pages, roughly every tenth of the total.
+type progress struct {
+	sync.Mutex
+	total    int
+	rendered int
+	interval int
+}
+
+func newProgress(total int) *progress {
+	interval := total / 10
+	if interval == 0 {
+		interval = 1
+	}
+	return &progress{total: total, interval: interval}
+}
+
+func (p *progress) done() {
+	p.Lock()
+	defer p.Unlock()
+	p.rendered++
+	if p.rendered%p.interval == 0 || p.rendered == p.total {
+		fmt.Printf("Rendered %d/%d specs\n", p.rendered, p.total)
+	}
+}
@@ -19,10 +19,15 @@ package generator
 
 import (
 	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
 
 	"path/filepath"
 
+	"github.com/getgauge/html-report/model"
 	helper "github.com/getgauge/html-report/test_helper"
 )
 
@@ -100,6 +105,37 @@ var wSuccRateLi = `
         <span>34%</span>
       </li>`
 
+var wEscapedBuildInfoLi = `
+        <li class="build-commit">
+          <label>Commit </label>
+          <span title="&#34;&gt;&lt;b&gt;x&lt;/b&gt;">&#34;&gt;&lt;b&gt;x&lt;</span>
+        </li>
+        <li class="build-number">
+          <label>Build </label>
+          <span>&lt;i&gt;CI&lt;/i&gt; #1</span>
+        </li>`
+
+var wBuildInfoLi = `
+     <li>
+        <label>Generated On </label>
+        <span>Jun 3, 2016 at 12:29pm</span>
+      </li>
+      <li class="build-commit">
+        <label>Commit </label>
+        <span title="0123456789abcdef">0123456 (master) <em class="dirty">with local changes</em></span>
+      </li>
+      <li class="build-number">
+        <label>Build </label>
+        <span><a href="https://ci.example/job/42" target="_blank">Jenkins #42</a></span>
+      </li>
+      <li class="build-agent">
+        <label>Agent </label>
+        <span>agent-1</span>
+      </li>
+    </ul>
+  </div>
+</div>`
+
 var wExecTimeLi = `
      <li>
         <label>Total Time </label>
@@ -115,27 +151,41 @@ var wTimestampLi = `
   </div>
 </div>`
 
+var wSpecListControls = `<div class="list-controls" data-scope="spec">
+    <select data-filter="tag" title="Tag"><option value="">All tags</option></select>
+    <select data-filter="error" title="Error type"><option value="">All failures</option></select>
+    <input data-filter="min" type="number" min="0" placeholder="Min s" title="Minimum duration in seconds" />
+    <input data-filter="max" type="number" min="0" placeholder="Max s" title="Maximum duration in seconds" />
+    <select data-filter="sort" title="Sort by">
+      <option value="">Status</option>
+      <option value="name">Name</option>
+      <option value="duration">Duration</option>
+      <option value="source">Source order</option>
+    </select>
+  </div>`
+
 var wSidebarAside = `<aside class="sidebar">
   <h3 class="title">Specifications</h3>
   <div class="searchbar">
-    <input id="searchSpecifications" placeholder="Type specification or tag name" type="text" />
+    <input id="searchSpecifications" placeholder="Search specifications, tags, steps or errors" type="text" />
     <i class="fa fa-search"></i>
   </div>
+  ` + wSpecListControls + `
   <div id="listOfSpecifications">
     <ul id="scenarios" class="spec-list">
-		<a href="passing_spec.html">
+		<a href="passing_spec.html" data-order="0" data-duration="0">
     	<li class='passed spec-name'>
 	      <span id="scenarioName" class="scenarioname">Passing Spec</span>
 	      <span id="time" class="time">00:01:04</span>
     	</li>
 		</a>
-		<a href="failing_spec.html">
+		<a href="failing_spec.html" data-order="0" data-duration="0" data-tags="smoke,it&#39;s">
     	<li class='failed spec-name'>
 	      <span id="scenarioName" class="scenarioname">Failing Spec</span>
 	      <span id="time" class="time">00:00:30</span>
     	</li>
 		</a>
-		<a href="skipped_spec.html">
+		<a href="skipped_spec.html" data-order="0" data-duration="0">
     	<li class='skipped spec-name'>
 	      <span id="scenarioName" class="scenarioname">Skipped Spec</span>
 	      <span id="time" class="time">00:00:00</span>
@@ -212,19 +262,23 @@ var wSpecCommentsWithTableTag = `<span></span>
   <tr>
     <th>Word</th>
     <th>Count</th>
+    <th class="row-time" title="Time taken by the scenarios of the row"><i class="fa fa-clock-o"></i></th>
   </tr>
   <tbody data-rowCount=3>
     <tr class='row-selector passed selected' data-rowIndex='0'>
       <td>Gauge</td>
       <td>3</td>
+      <td class="row-time"></td>
     </tr>
     <tr class='row-selector failed' data-rowIndex='1'>
       <td>Mingle</td>
       <td>2</td>
+      <td class="row-time">00:00:12</td>
     </tr>
     <tr class='row-selector skipped' data-rowIndex='2'>
       <td>foobar</td>
       <td>1</td>
+      <td class="row-time"></td>
     </tr>
   </tbody>
 </table>
@@ -240,9 +294,9 @@ var wSpecCommentsWithoutTableTag = `<span></span>
 
 var wSpecCommentsWithCodeBlock = `<span><pre><code>{&#34;prop&#34;:&#34;value&#34;}</code></pre></span>`
 
-var wScenarioContainerStartPassDiv = `<div class='scenario-container passed'>`
-var wScenarioContainerStartFailDiv = `<div class='scenario-container failed'>`
-var wScenarioContainerStartSkipDiv = `<div class='scenario-container skipped'>`
+var wScenarioContainerStartPassDiv = `<div data-order='0' data-duration='0' class='scenario-container passed'>`
+var wScenarioContainerStartFailDiv = `<div data-order='1' data-duration='61000' data-tags='smoke' class='scenario-container failed'>`
+var wScenarioContainerStartSkipDiv = `<div data-order='0' data-duration='0' class='scenario-container skipped'>`
 
 var wscenarioHeaderStartDiv = `<div class="scenario-head">
   <h3 class="head borderBottom">Scenario Heading</h3>
@@ -262,6 +316,20 @@ var wFailStepStartDiv = `<div class='step'>
       <li class='step'>
         <div class='step-txt'>`
 
+var wRecoverableStepStartDiv = `<div class='step'>
+  <h5 class='execution-time'><span class='time'>Execution Time : 00:03:31</span></h5>
+  <div class='step-info recoverable-fail' title='Failed, execution continued'>
+    <ul>
+      <li class='step'>
+        <div class='step-txt'>`
+
+var wSpecSourceWithStatuses = `<div id="specSource" class="spec-source hidden">
+  <table>
+    <tr><td class="gutter recoverable-fail"></td><td class="line-number">1</td><td class="line-text">* Soft check</td></tr>
+    <tr><td class="gutter not-executed"></td><td class="line-number">2</td><td class="line-text">* Later step</td></tr>
+  </table>
+</div>`
+
 var wSkipStepStartDiv = `<div class='step'>
   <div class='step-info skipped'>
     <ul>
@@ -415,15 +483,20 @@ var skippedStepRes = &result{
 
 var reportGenTests = []reportGenTest{
 	{"generate html page start with project name", "htmlPageStartTag", &overview{ProjectName: "projname"}, whtmlPageStartTag},
-	{"generate report overview with tags", "reportOverviewTag", &overview{"projname", "default", "foo", 34, "00:01:53", "Jun 3, 2016 at 12:29pm", &summary{41, 2, 39, 0}, "/"},
+	{"generate report overview with tags", "reportOverviewTag", &overview{"projname", "default", "foo", 34, "00:01:53", "Jun 3, 2016 at 12:29pm", &summary{41, 2, 39, 0, 0}, "/", nil, nil, 0},
 		wChartDiv + wResCntDiv + wEnvLi + wTagsLi + wSuccRateLi + wExecTimeLi + wTimestampLi},
-	{"generate report overview without tags", "reportOverviewTag", &overview{"projname", "default", "", 34, "00:01:53", "Jun 3, 2016 at 12:29pm", &summary{41, 2, 39, 0}, "/"},
+	{"generate report overview without tags", "reportOverviewTag", &overview{"projname", "default", "", 34, "00:01:53", "Jun 3, 2016 at 12:29pm", &summary{41, 2, 39, 0, 0}, "/", nil, nil, 0},
 		wChartDiv + wResCntDiv + wEnvLi + wSuccRateLi + wExecTimeLi + wTimestampLi},
+	{"generate report overview with build info", "reportOverviewTag", &overview{"projname", "default", "", 34, "00:01:53", "Jun 3, 2016 at 12:29pm", &summary{41, 2, 39, 0, 0}, "/",
+		&model.BuildInfo{Commit: "0123456789abcdef", Branch: "master", Dirty: true, CI: "Jenkins", BuildNumber: "42", JobURL: "https://ci.example/job/42", AgentName: "agent-1"}, nil, 0},
+		wChartDiv + wResCntDiv + wEnvLi + wSuccRateLi + wExecTimeLi + wBuildInfoLi},
+	{"escape build info taken from the environment", "buildInfo",
+		&model.BuildInfo{Commit: `"><b>x</b>`, CI: "<i>CI</i>", BuildNumber: "1", JobURL: "javascript:alert(1)"}, wEscapedBuildInfoLi},
 	{"generate sidebar with appropriate pass/fail/skip class", "sidebarDiv", &sidebar{
 		IsBeforeHookFailure: false,
 		Specs: []*specsMeta{
 			newSpecsMeta("Passing Spec", "00:01:04", false, false, nil, "passing_spec.html"),
-			newSpecsMeta("Failing Spec", "00:00:30", true, false, nil, "failing_spec.html"),
+			newSpecsMeta("Failing Spec", "00:00:30", true, false, []string{"smoke", "it's"}, "failing_spec.html"),
 			newSpecsMeta("Skipped Spec", "00:00:00", false, true, nil, "skipped_spec.html"),
 		}}, wSidebarAside},
 	{"do not generate sidebar if presuitehook failure", "sidebarDiv", &sidebar{
@@ -432,18 +505,23 @@ var reportGenTests = []reportGenTest{
 	}, ""},
 	{"generate hook failure div with screenshot", "hookFailureDiv", newHookFailure("BeforeSuite", "SomeError", "iVBO", "Stack trace"), wHookFailureWithScreenhotDiv},
 	{"generate hook failure div without screenshot", "hookFailureDiv", newHookFailure("BeforeSuite", "SomeError", "", "Stack trace"), wHookFailureWithoutScreenhotDiv},
-	{"generate spec header with tags", "specHeaderStartTag", &specHeader{"Spec heading", "00:01:01", "/tmp/gauge/specs/foobar.spec", []string{"foo", "bar"}, &summary{0, 0, 0, 0}}, wSpecHeaderStartWithTags},
+	{"generate spec header with tags", "specHeaderStartTag", &specHeader{"Spec heading", "00:01:01", "/tmp/gauge/specs/foobar.spec", "", []string{"foo", "bar"}, &summary{0, 0, 0, 0, 0}}, wSpecHeaderStartWithTags},
 	{"generate div for tags", "tagsDiv", &specHeader{Tags: []string{"tag1", "tag2"}}, wTagsDiv},
 	{"generate spec comments with data table (if present)", "specCommentsAndTableTag", newSpec(true), wSpecCommentsWithTableTag},
 	{"generate spec comments without data table", "specCommentsAndTableTag", newSpec(false), wSpecCommentsWithoutTableTag},
 	{"generate spec comments with code block", "specCommentsAndTableTag", stepWithCodeBlock, wSpecCommentsWithCodeBlock},
 	{"generate passing scenario container", "scenarioContainerStartDiv", &scenario{ExecutionStatus: pass, TableRowIndex: -1}, wScenarioContainerStartPassDiv},
-	{"generate failed scenario container", "scenarioContainerStartDiv", &scenario{ExecutionStatus: fail, TableRowIndex: -1}, wScenarioContainerStartFailDiv},
+	{"generate failed scenario container", "scenarioContainerStartDiv", &scenario{ExecutionStatus: fail, TableRowIndex: -1, Position: 1, Duration: 61000, Tags: []string{"smoke"}}, wScenarioContainerStartFailDiv},
 	{"generate skipped scenario container", "scenarioContainerStartDiv", &scenario{ExecutionStatus: skip, TableRowIndex: -1}, wScenarioContainerStartSkipDiv},
 	{"generate scenario header", "scenarioHeaderStartDiv", &scenario{Heading: "Scenario Heading", ExecutionTime: "00:01:01"}, wscenarioHeaderStartDiv},
 	{"generate pass step start div", "stepStartDiv", newStep(pass), wPassStepStartDiv},
 	{"generate fail step start div", "stepStartDiv", newStep(fail), wFailStepStartDiv},
 	{"generate skipped step start div", "stepStartDiv", newStep(skip), wSkipStepStartDiv},
+	{"generate recoverable step start div", "stepStartDiv", newStep(recoverableFail), wRecoverableStepStartDiv},
+	{"generate spec source with a single class for each status", "specSource", &sourceView{Lines: []*sourceLine{
+		{Number: 1, Text: "* Soft check", Status: recoverableFail},
+		{Number: 2, Text: "* Later step", Status: notExecuted},
+	}}, wSpecSourceWithStatuses},
 	{"generate skipped step body div", "stepBodyDiv", stepWithBracketsInFragment, wPassStepBodyDivWithBracketsInFragment},
 	{"generate skipped step skipped reason div", "skippedReasonDiv", skippedStepRes, wSkippedStepWithSkippedReason},
 	{"generate step body div with file special param", "stepBodyDiv", stepWithFileParam, wStepWithFileParam},
@@ -510,8 +588,9 @@ func newSpec(withTable bool) *spec {
 				Result: pass,
 			},
 			{
-				Cells:  []string{"Mingle", "2"},
-				Result: fail,
+				Cells:         []string{"Mingle", "2"},
+				Result:        fail,
+				ExecutionTime: "00:00:12",
 			},
 			{
 				Cells:  []string{"foobar", "1"},
@@ -561,15 +640,75 @@ func newStep(s status) *step {
 }
 
 func TestGetAbsThemePathForRelPath(t *testing.T) {
-	oldProjectRoot := projectRoot
-	projectRoot, _ = filepath.Abs(filepath.Join("Dummy", "Project", "Root"))
+	projectRoot, _ := filepath.Abs(filepath.Join("Dummy", "Project", "Root"))
 	themePath := filepath.Join("some", "path")
 	want := filepath.Join(projectRoot, themePath)
 
-	got := getAbsThemePath(themePath)
+	got := getAbsThemePath(projectRoot, themePath)
 
 	if want != got {
 		t.Errorf("Expected theme path = %s, got %s", want, got)
 	}
-	projectRoot = oldProjectRoot
+}
+
+func TestGenerateSpecPagesWithBoundedWorkers(t *testing.T) {
+	reportDir, err := ioutil.TempDir("", "spec-pages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(reportDir)
+	readTemplates(templateBasePath)
+	res := &SuiteResult{ProjectName: "foo"}
+	for i := 0; i < 25; i++ {
+		res.SpecResults = append(res.SpecResults, &spec{SpecHeading: fmt.Sprintf("spec %d", i), FileName: fmt.Sprintf("spec_%d.spec", i), ExecutionStatus: pass})
+	}
+
+	err = generateSpecPages(res, reportDir, 3)
+
+	if err != nil {
+		t.Errorf("Expected error to be nil. Got: %s", err.Error())
+	}
+	for _, s := range res.SpecResults {
+		p := filepath.Join(reportDir, toHTMLFileName(s.FileName, res.ProjectRoot))
+		if !helper.FileExists(p) {
+			t.Errorf("Expected spec page %s to be generated", p)
+		}
+	}
+}
+
+func TestGenerateReportsReturnsTemplateErrors(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "broken-theme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	os.MkdirAll(filepath.Join(tmp, "views"), 0755)
+	ioutil.WriteFile(filepath.Join(tmp, "views", "partials.tmpl"), []byte(`{{define "indexPage"}}{{.NoSuchField}}{{end}}`), 0644)
+
+	err = GenerateReports(&SuiteResult{}, tmp, tmp)
+
+	if err == nil || !strings.Contains(err.Error(), "NoSuchField") {
+		t.Errorf("Expected the template error to be returned, got: %v", err)
+	}
+	readTemplates(templateBasePath)
+}
+
+func TestGenerateSpecPagesReturnsFileErrors(t *testing.T) {
+	reportDir, err := ioutil.TempDir("", "spec-pages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(reportDir)
+	os.Mkdir(filepath.Join(reportDir, "foo.html"), 0755)
+	readTemplates(templateBasePath)
+	res := &SuiteResult{SpecResults: []*spec{{FileName: "foo.spec"}, {FileName: "bar.spec"}}}
+
+	err = generateSpecPages(res, reportDir, 2)
+
+	if err == nil {
+		t.Errorf("Expected error when spec page cannot be created")
+	}
+	if !helper.FileExists(filepath.Join(reportDir, "bar.html")) {
+		t.Errorf("Expected remaining spec pages to be generated")
+	}
 }
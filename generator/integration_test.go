@@ -786,11 +786,8 @@ func TestHTMLGeneration(t *testing.T) {
 		}
 
 		buf := new(bytes.Buffer)
-		var wg sync.WaitGroup
-		wg.Add(1)
 
-		generateSpecPage(test.res, test.res.SpecResults[0], buf, &wg)
-		wg.Wait()
+		generateSpecPage(test.res, test.res.SpecResults[0], buf)
 
 		want := helper.RemoveNewline(string(content))
 		got := helper.RemoveNewline(buf.String())
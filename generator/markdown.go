@@ -0,0 +1,340 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/getgauge/common"
+	"github.com/getgauge/html-report/env"
+	"github.com/getgauge/html-report/model"
+)
+
+const (
+	markdownFormat     = "markdown"
+	markdownFile       = "summary.md"
+	slowestSpecsCount  = 5
+	maxStackTraceLines = 30
+	// truncationNoteSize is kept free for the note telling what was left out of a markdown summary.
+	truncationNoteSize = 256
+)
+
+// markdownExporter writes a short markdown summary of the suite, meant for pull request comments
+// and CI job summaries. maxSize is the size in bytes the summary is kept within, which defaults
+// to the html_report_markdown_max_size property.
+type markdownExporter struct {
+	maxSize int
+}
+
+func (e *markdownExporter) Export(res *SuiteResult, reportsDir string) error {
+	maxSize := e.maxSize
+	if maxSize <= 0 {
+		maxSize = env.GetMarkdownMaxSize()
+	}
+	if maxSize < truncationNoteSize {
+		return fmt.Errorf("markdown summary needs at least %d bytes, %s is %d", truncationNoteSize, env.MarkdownMaxSize, maxSize)
+	}
+	p := filepath.Join(reportsDir, markdownFile)
+	if err := ioutil.WriteFile(p, []byte(toMarkdown(res, maxSize)), common.NewFilePermissions); err != nil {
+		return err
+	}
+	fmt.Printf("Successfully generated markdown report to => %s\n", p)
+	return nil
+}
+
+// toMarkdown renders the summary of res. The status line, the counts and the suite hook failures
+// are included as long as they fit, and cut at the last whole line otherwise. The slowest specs are left out if they do not fit within maxSize, and
+// failed scenarios are listed until the summary is full, first without their stack traces, then
+// leaving out the remaining ones, with a note on what was left out.
+func toMarkdown(res *SuiteResult, maxSize int) string {
+	var b bytes.Buffer
+	writeMarkdownHeader(&b, res)
+	if b.Len() > maxSize-truncationNoteSize {
+		header := cutAtLine(b.String(), maxSize-truncationNoteSize)
+		return header + fmt.Sprintf("\n> **Note:** this summary is cut to %d bytes. See the [html report](index.html) for the full result.\n", maxSize)
+	}
+	slowest := markdownSlowestSpecs(res)
+	budget := maxSize - b.Len() - truncationNoteSize
+	if len(slowest) > budget {
+		slowest = ""
+	}
+	budget -= len(slowest)
+
+	var failures bytes.Buffer
+	shown, omitted, tracesOmitted := 0, 0, 0
+	for _, s := range res.SpecResults {
+		if s == nil || s.ExecutionStatus != fail {
+			continue
+		}
+		heading := markdownSpecFailures(res.ProjectRoot, s)
+		entries, short := markdownScenarioFailures(res.ProjectRoot, s)
+		if omitted > 0 || failures.Len()+len(heading) > budget {
+			omitted += len(entries)
+			continue
+		}
+		failures.WriteString(heading)
+		for i := range entries {
+			switch {
+			case omitted == 0 && failures.Len()+len(entries[i]) <= budget:
+				failures.WriteString(entries[i])
+				shown++
+			case omitted == 0 && failures.Len()+len(short[i]) <= budget:
+				failures.WriteString(short[i])
+				shown++
+				tracesOmitted++
+			default:
+				omitted++
+			}
+		}
+	}
+	if failures.Len() > 0 {
+		b.WriteString("\n### Failures\n")
+		b.WriteString(failures.String())
+	}
+	if omitted > 0 || tracesOmitted > 0 {
+		fmt.Fprintf(&b, "\n> **Note:** this summary is cut to %d bytes.", maxSize)
+		if tracesOmitted > 0 {
+			fmt.Fprintf(&b, " Stack traces of %d failed scenarios are not shown.", tracesOmitted)
+		}
+		if omitted > 0 {
+			fmt.Fprintf(&b, " %d failed scenarios are not shown.", omitted)
+		}
+		b.WriteString(" See the [html report](index.html) for all of them.\n")
+	}
+	if slowest != "" {
+		b.WriteString(slowest)
+	}
+	return b.String()
+}
+
+func writeMarkdownHeader(b *bytes.Buffer, res *SuiteResult) {
+	badge := "✅ Passed"
+	if res.ExecutionStatus == fail {
+		badge = "❌ Failed"
+	}
+	fmt.Fprintf(b, "## %s\n\n", strings.Join(nonEmpty("**"+badge+"**", escapeMarkdown(res.ProjectName), escapeMarkdown(res.Environment),
+		fmt.Sprintf("%.2f%% success rate", res.SuccessRate), formatTime(res.ExecutionTime)), " · "))
+	if line := markdownBuildLine(res.Build); line != "" {
+		fmt.Fprintf(b, "%s\n\n", line)
+	}
+	p, f, s := countScenarios(res)
+	b.WriteString("| | Passed | Failed | Skipped | Total |\n|---|---:|---:|---:|---:|\n")
+	fmt.Fprintf(b, "| Specifications | %d | %d | %d | %d |\n", res.PassedSpecsCount, res.FailedSpecsCount, res.SkippedSpecsCount,
+		res.PassedSpecsCount+res.FailedSpecsCount+res.SkippedSpecsCount)
+	fmt.Fprintf(b, "| Scenarios | %d | %d | %d | %d |\n", p, f, s, p+f+s)
+	hooks := nonNilHookFailures(res.BeforeSuiteHookFailure, res.AfterSuiteHookFailure)
+	if len(hooks) > 0 {
+		b.WriteString("\n### Hook failures\n\n")
+		for _, h := range hooks {
+			fmt.Fprintf(b, "- **%s**: %s\n", escapeMarkdown(h.HookName), escapeMarkdown(firstLine(h.ErrMsg)))
+		}
+	}
+}
+
+func markdownBuildLine(build *model.BuildInfo) string {
+	if build == nil {
+		return ""
+	}
+	var parts []string
+	if build.Commit != "" {
+		commit := "Commit " + markdownCode(build.ShortCommit())
+		if build.Branch != "" {
+			commit += " on " + markdownCode(build.Branch)
+		}
+		if build.Dirty {
+			commit += " (uncommitted changes)"
+		}
+		parts = append(parts, commit)
+	}
+	if build.CI != "" {
+		job := escapeMarkdown(strings.TrimSpace(build.CI + " #" + build.BuildNumber))
+		if build.BuildNumber == "" {
+			job = escapeMarkdown(build.CI)
+		}
+		if u := markdownURL(build.JobURL); u != "" {
+			job = fmt.Sprintf("[%s](%s)", job, u)
+		}
+		parts = append(parts, job)
+	}
+	return strings.Join(parts, " · ")
+}
+
+func markdownSpecFailures(root string, s *spec) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "\n#### [%s](%s)\n\n", escapeMarkdown(s.SpecHeading), markdownReportLink(root, s, ""))
+	for _, e := range s.Errors {
+		fmt.Fprintf(&b, "- %s\n", escapeMarkdown(firstLine(e.Error())))
+	}
+	for _, h := range append(append([]*hookFailure{}, s.BeforeSpecHookFailures...), s.AfterSpecHookFailures...) {
+		fmt.Fprintf(&b, "- **%s**: %s\n", escapeMarkdown(h.HookName), escapeMarkdown(firstLine(h.ErrMsg)))
+	}
+	return b.String()
+}
+
+// markdownScenarioFailures returns an entry for each failed scenario of s, along with a shorter
+// variant of each that leaves out the stack trace.
+func markdownScenarioFailures(root string, s *spec) (entries, short []string) {
+	for _, scn := range s.Scenarios {
+		if scn.ExecutionStatus != fail && scn.ExecutionStatus != recoverableFail {
+			continue
+		}
+		line := fmt.Sprintf("- [%s](%s)", escapeMarkdown(scn.Heading), markdownReportLink(root, s, scn.Anchor))
+		if msg := firstScenarioError(scn); msg != "" {
+			line += ": " + escapeMarkdown(firstLine(msg))
+		}
+		line += "\n"
+		entry := line
+		if trace := firstScenarioStackTrace(scn); trace != "" {
+			fence := markdownFence(trace)
+			entry += fmt.Sprintf("  <details><summary>Stack trace</summary>\n\n  %s\n%s\n  %s\n\n  </details>\n", fence, indent(limitLines(trace, maxStackTraceLines), "  "), fence)
+		}
+		entries = append(entries, entry)
+		short = append(short, line)
+	}
+	return
+}
+
+// firstScenarioStackTrace returns the stack trace of the error firstScenarioError returns.
+func firstScenarioStackTrace(scn *scenario) string {
+	if scn.BeforeScenarioHookFailure != nil {
+		return scn.BeforeScenarioHookFailure.StackTrace
+	}
+	for _, st := range scn.Steps() {
+		if st.BeforeStepHookFailure != nil {
+			return st.BeforeStepHookFailure.StackTrace
+		}
+		if st.Result != nil && (st.Result.Status == fail || st.Result.Status == recoverableFail) {
+			return st.Result.StackTrace
+		}
+		if st.AfterStepHookFailure != nil {
+			return st.AfterStepHookFailure.StackTrace
+		}
+	}
+	if scn.AfterScenarioHookFailure != nil {
+		return scn.AfterScenarioHookFailure.StackTrace
+	}
+	return ""
+}
+
+func markdownSlowestSpecs(res *SuiteResult) string {
+	var specs []*spec
+	for _, s := range res.SpecResults {
+		if s != nil {
+			specs = append(specs, s)
+		}
+	}
+	if len(specs) == 0 {
+		return ""
+	}
+	sort.SliceStable(specs, func(i, j int) bool { return specs[i].ExecutionTime > specs[j].ExecutionTime })
+	if len(specs) > slowestSpecsCount {
+		specs = specs[:slowestSpecsCount]
+	}
+	var b bytes.Buffer
+	b.WriteString("\n### Slowest specs\n\n| Spec | Status | Duration |\n|---|---|---:|\n")
+	for _, s := range specs {
+		fmt.Fprintf(&b, "| [%s](%s) | %s | %s |\n", escapeMarkdown(s.SpecHeading), markdownReportLink(res.ProjectRoot, s, ""), s.ExecutionStatus, formatTime(s.ExecutionTime))
+	}
+	return b.String()
+}
+
+// markdownReportLink links to the page of a spec, relative to the root of the report.
+func markdownReportLink(root string, s *spec, anchor string) string {
+	u := &url.URL{Path: filepath.ToSlash(toHTMLFileName(s.FileName, root)), Fragment: anchor}
+	return u.String()
+}
+
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\", "`", "\\`", "*", "\\*", "_", "\\_", "[", "\\[", "]", "\\]",
+	"<", "&lt;", ">", "&gt;", "|", "\\|",
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// markdownFence returns a code fence longer than any run of backticks in s.
+func markdownFence(s string) string {
+	longest := longestBacktickRun(s)
+	if longest < 3 {
+		return "```"
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+// markdownCode returns s as an inline code span, delimited by more backticks than any run of
+// backticks in s.
+func markdownCode(s string) string {
+	s = strings.Replace(strings.Replace(s, "\r", " ", -1), "\n", " ", -1)
+	delim := strings.Repeat("`", longestBacktickRun(s)+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return delim + s + delim
+}
+
+// longestBacktickRun returns the length of the longest run of backticks in s.
+func longestBacktickRun(s string) int {
+	longest, run := 0, 0
+	for _, c := range s {
+		if c == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
+
+// markdownURL returns s as the destination of a markdown link, or an empty string if it is not
+// an http(s) url. Parentheses are escaped, as a closing one would end the link.
+func markdownURL(s string) string {
+	return strings.NewReplacer("(", "%28", ")", "%29").Replace(toHTTPURL(s))
+}
+
+// cutAtLine returns the longest prefix of s made of whole lines that is at most n bytes long.
+func cutAtLine(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if n <= 0 {
+		return ""
+	}
+	return s[:strings.LastIndex(s[:n], "\n")+1]
+}
+
+func limitLines(s string, max int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) <= max {
+		return strings.Join(lines, "\n")
+	}
+	return strings.Join(lines[:max], "\n") + fmt.Sprintf("\n… %d more lines", len(lines)-max)
+}
+
+func indent(s, prefix string) string {
+	return prefix + strings.Replace(s, "\n", "\n"+prefix, -1)
+}
@@ -0,0 +1,234 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/getgauge/html-report/model"
+)
+
+func TestToMarkdown(t *testing.T) {
+	res := summarySuiteRes()
+	res.ExecutionStatus = fail
+	res.Build = &model.BuildInfo{Commit: "0123456789abcdef", Branch: "main", CI: "GitHub Actions", BuildNumber: "12", JobURL: "https://ci.example/12"}
+	res.SpecResults[0].ExecutionTime = 1000
+	res.SpecResults[1].ExecutionTime = 2000
+	res.SpecResults[1].Scenarios[0].Items[1].Step.Result.StackTrace = "at step.go:1\nat main.go:2"
+	want := "## **❌ Failed** · Gauge Project · default · 50.00% success rate · 00:02:02\n" +
+		"\n" +
+		"Commit `0123456` on `main` · [GitHub Actions #12](https://ci.example/12)\n" +
+		"\n" +
+		"| | Passed | Failed | Skipped | Total |\n" +
+		"|---|---:|---:|---:|---:|\n" +
+		"| Specifications | 1 | 1 | 0 | 2 |\n" +
+		"| Scenarios | 1 | 2 | 1 | 4 |\n" +
+		"\n" +
+		"### Hook failures\n" +
+		"\n" +
+		"- **After Suite**: cleanup failed\n" +
+		"\n" +
+		"### Failures\n" +
+		"\n" +
+		"#### [Failing](specs/failing.html)\n" +
+		"\n" +
+		"- [Failing scenario](specs/failing.html#scenario-failing): expected 1 to equal 2\n" +
+		"  <details><summary>Stack trace</summary>\n" +
+		"\n" +
+		"  ```\n" +
+		"  at step.go:1\n" +
+		"  at main.go:2\n" +
+		"  ```\n" +
+		"\n" +
+		"  </details>\n" +
+		"- [Hook failing scenario](specs/failing.html#scenario-hook): no database\n" +
+		"\n" +
+		"### Slowest specs\n" +
+		"\n" +
+		"| Spec | Status | Duration |\n" +
+		"|---|---|---:|\n" +
+		"| [Failing](specs/failing.html) | fail | 00:00:02 |\n" +
+		"| [Passing](specs/passing.html) | pass | 00:00:01 |\n"
+
+	got := toMarkdown(res, 65536)
+
+	if got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestToMarkdownKeepsWithinMaxSize(t *testing.T) {
+	res := summarySuiteRes()
+	var scenarios []*scenario
+	for i := 0; i < 100; i++ {
+		scenarios = append(scenarios, &scenario{
+			Heading:                   fmt.Sprintf("Scenario %d", i),
+			ExecutionStatus:           fail,
+			BeforeScenarioHookFailure: &hookFailure{ErrMsg: "failed", StackTrace: strings.Repeat("at step.go:1\n", 20)},
+		})
+	}
+	res.SpecResults[1].Scenarios = scenarios
+	maxSize := 4096
+
+	got := toMarkdown(res, maxSize)
+
+	if len(got) > maxSize {
+		t.Errorf("want at most %d bytes, got: %d", maxSize, len(got))
+	}
+	if !strings.Contains(got, "failed scenarios are not shown") || !strings.Contains(got, "[html report](index.html)") {
+		t.Errorf("want a truncation note, got:\n%s", got)
+	}
+	if !strings.Contains(got, "- [Scenario 0]") {
+		t.Errorf("want the first failed scenario to be listed, got:\n%s", got)
+	}
+}
+
+func TestToMarkdownCutsHeaderLongerThanMaxSize(t *testing.T) {
+	res := summarySuiteRes()
+	res.AfterSuiteHookFailure.ErrMsg = strings.Repeat("cleanup failed ", 500)
+	maxSize := 1024
+
+	got := toMarkdown(res, maxSize)
+
+	if len(got) > maxSize {
+		t.Errorf("want at most %d bytes, got: %d", maxSize, len(got))
+	}
+	if !strings.HasPrefix(got, "## **✅ Passed**") && !strings.HasPrefix(got, "## **❌ Failed**") {
+		t.Errorf("want the status line, got:\n%s", got)
+	}
+	if strings.Contains(got, "cleanup failed") || !strings.Contains(got, "[html report](index.html)") {
+		t.Errorf("want the hook failure left out with a truncation note, got:\n%s", got)
+	}
+}
+
+func TestMarkdownExporterFailsWithMaxSizeBelowTruncationNote(t *testing.T) {
+	dir, err := ioutil.TempDir("", "markdown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := (&markdownExporter{maxSize: 100}).Export(&SuiteResult{ProjectName: "Gauge Project"}, dir); err == nil {
+		t.Errorf("Expected an error for a max size of 100 bytes")
+	}
+}
+
+func TestToMarkdownShowsStackTraceOfRecoverableFailure(t *testing.T) {
+	res := summarySuiteRes()
+	step := res.SpecResults[1].Scenarios[0].Items[1].Step
+	step.Result.Status = recoverableFail
+	step.Result.StackTrace = "at recoverable.go:1"
+	res.SpecResults[1].Scenarios[0].ExecutionStatus = recoverableFail
+
+	got := toMarkdown(res, 65536)
+
+	if !strings.Contains(got, "at recoverable.go:1") {
+		t.Errorf("want the stack trace of the recoverable failure, got:\n%s", got)
+	}
+}
+
+func TestMarkdownBuildLineEscapesBranch(t *testing.T) {
+	want := "Commit `0123456` on ``a`b *c*`` · CI \\* \\[x\\]"
+
+	got := markdownBuildLine(&model.BuildInfo{Commit: "0123456789", Branch: "a`b *c*", CI: "CI * [x]"})
+
+	if got != want {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
+
+func TestMarkdownBuildLineLinksOnlyHTTPJobURLs(t *testing.T) {
+	for jobURL, want := range map[string]string{
+		"https://ci.example/a)(b": "[CI #1](https://ci.example/a%29%28b)",
+		"javascript:alert(1)":     "CI #1",
+		"":                        "CI #1",
+	} {
+		got := markdownBuildLine(&model.BuildInfo{CI: "CI", BuildNumber: "1", JobURL: jobURL})
+
+		if got != want {
+			t.Errorf("want: %q, got: %q", want, got)
+		}
+	}
+}
+
+func TestMarkdownCode(t *testing.T) {
+	for s, want := range map[string]string{"main": "`main`", "a``b": "```a``b```", "`a": "`` `a ``", "a\nb": "`a b`"} {
+		if got := markdownCode(s); got != want {
+			t.Errorf("want: %q, got: %q", want, got)
+		}
+	}
+}
+
+func TestToMarkdownLeavesOutStackTracesBeforeScenarios(t *testing.T) {
+	res := summarySuiteRes()
+	res.SpecResults[1].Scenarios[1].BeforeScenarioHookFailure.StackTrace = strings.Repeat("x", 2000)
+	full := toMarkdown(res, 65536)
+
+	got := toMarkdown(res, len(full)-1000)
+
+	if strings.Contains(got, strings.Repeat("x", 2000)) || !strings.Contains(got, "- [Hook failing scenario]") {
+		t.Errorf("want the scenario without its stack trace, got:\n%s", got)
+	}
+	if !strings.Contains(got, "Stack traces of 1 failed scenarios are not shown.") {
+		t.Errorf("want a note on the stack traces left out, got:\n%s", got)
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	want := "a \\| b \\*c\\* \\[d\\] &lt;e&gt;"
+
+	got := escapeMarkdown("a | b *c* [d] <e>")
+
+	if got != want {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
+
+func TestMarkdownFenceIsLongerThanBackticksInContent(t *testing.T) {
+	if got := markdownFence("a ```` b"); got != "`````" {
+		t.Errorf("want: %q, got: %q", "`````", got)
+	}
+	if got := markdownFence("a ` b"); got != "```" {
+		t.Errorf("want: %q, got: %q", "```", got)
+	}
+}
+
+func TestMarkdownExporterWritesSummary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "markdown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := (&markdownExporter{maxSize: 65536}).Export(&SuiteResult{ProjectName: "Gauge Project"}, dir); err != nil {
+		t.Fatalf("Expected error to be nil. Got: %s", err.Error())
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, markdownFile))
+	if err != nil {
+		t.Fatalf("Expected %s to be written. Got: %s", markdownFile, err.Error())
+	}
+	if !strings.HasPrefix(string(b), "## **✅ Passed** · Gauge Project") {
+		t.Errorf("Unexpected summary: %s", string(b))
+	}
+}
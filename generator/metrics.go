@@ -0,0 +1,249 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getgauge/common"
+	"github.com/getgauge/html-report/env"
+)
+
+const (
+	metricsFormat = "metrics"
+	metricsFile   = "gauge.prom"
+	specLabel     = "spec"
+	tagLabel      = "tag"
+)
+
+// metricsExporter writes run metrics in the OpenMetrics text format, to be scraped through the
+// textfile collector of node_exporter. Only gauges are written, so that the file can be read as
+// the Prometheus text format too.
+type metricsExporter struct {
+}
+
+func (e *metricsExporter) Export(res *SuiteResult, reportsDir string) error {
+	p := env.GetMetricsFile()
+	if p == "" {
+		p = filepath.Join(reportsDir, metricsFile)
+	}
+	opts := metricsOptions{maxSeries: env.GetMetricsMaxSeries()}
+	for _, l := range env.GetMetricsLabels() {
+		opts.bySpec = opts.bySpec || l == specLabel
+		opts.byTag = opts.byTag || l == tagLabel
+	}
+	if err := writeFileAtomically(p, []byte(toMetrics(res, opts))); err != nil {
+		return err
+	}
+	fmt.Printf("Successfully generated metrics to => %s\n", p)
+	return nil
+}
+
+// metricsOptions limit the cardinality of the metrics: whether they are broken down by spec and
+// by tag, and by how many specs or tags at most. The slowest specs and the tags with the most
+// scenarios are kept.
+type metricsOptions struct {
+	bySpec    bool
+	byTag     bool
+	maxSeries int
+}
+
+type metricFamily struct {
+	name   string
+	help   string
+	unit   string
+	series []metricSeries
+}
+
+type metricSeries struct {
+	labels [][2]string
+	value  float64
+}
+
+func toMetrics(res *SuiteResult, opts metricsOptions) string {
+	suiteLabels := [][2]string{{"project", res.ProjectName}, {"environment", res.Environment}}
+	with := func(labels ...[2]string) [][2]string {
+		return append(append([][2]string{}, suiteLabels...), labels...)
+	}
+	families := []*metricFamily{
+		{name: "gauge_suite_duration_seconds", unit: "seconds", help: "Time taken to execute the suite.",
+			series: []metricSeries{{labels: with(), value: seconds(res.ExecutionTime)}}},
+		{name: "gauge_suite_success_ratio", help: "Ratio of the specs that passed.",
+			series: []metricSeries{{labels: with(), value: float64(res.SuccessRate) / 100}}},
+		{name: "gauge_specs", help: "Number of specs by status.", series: []metricSeries{
+			{labels: with([2]string{"status", "passed"}), value: float64(res.PassedSpecsCount)},
+			{labels: with([2]string{"status", "failed"}), value: float64(res.FailedSpecsCount)},
+			{labels: with([2]string{"status", "skipped"}), value: float64(res.SkippedSpecsCount)},
+		}},
+	}
+	p, f, s := countScenarios(res)
+	families = append(families, &metricFamily{name: "gauge_scenarios", help: "Number of scenarios by status.", series: []metricSeries{
+		{labels: with([2]string{"status", "passed"}), value: float64(p)},
+		{labels: with([2]string{"status", "failed"}), value: float64(f)},
+		{labels: with([2]string{"status", "skipped"}), value: float64(s)},
+	}})
+	if res.Build != nil {
+		labels := with()
+		for _, l := range [][2]string{{"commit", res.Build.Commit}, {"branch", res.Build.Branch}, {"ci", res.Build.CI}, {"build_number", res.Build.BuildNumber}} {
+			if l[1] != "" {
+				labels = append(labels, l)
+			}
+		}
+		families = append(families, &metricFamily{name: "gauge_build_info", help: "Build the suite was executed for.",
+			series: []metricSeries{{labels: labels, value: 1}}})
+	}
+	dropped := &metricFamily{name: "gauge_metrics_dropped_series", help: "Number of series left out to keep within the configured cardinality."}
+	if opts.bySpec {
+		durations := &metricFamily{name: "gauge_spec_duration_seconds", unit: "seconds", help: "Time taken to execute each spec."}
+		for _, sp := range res.SpecResults {
+			if sp != nil {
+				durations.series = append(durations.series, metricSeries{labels: with([2]string{"spec", relativeSpecPath(res.ProjectRoot, sp.FileName)}), value: seconds(sp.ExecutionTime)})
+			}
+		}
+		sort.SliceStable(durations.series, func(i, j int) bool { return durations.series[i].value > durations.series[j].value })
+		families = append(families, limitSeries(durations, opts.maxSeries, dropped, with))
+	}
+	if opts.byTag {
+		// Each tag has a series per status.
+		families = append(families, limitSeries(tagScenarioCounts(res, with), opts.maxSeries*3, dropped, with))
+	}
+	if len(dropped.series) > 0 {
+		families = append(families, dropped)
+	}
+
+	var b bytes.Buffer
+	for _, family := range families {
+		if len(family.series) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", family.name)
+		if family.unit != "" {
+			fmt.Fprintf(&b, "# UNIT %s %s\n", family.name, family.unit)
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n", family.name, family.help)
+		for _, s := range family.series {
+			fmt.Fprintf(&b, "%s{%s} %s\n", family.name, formatLabels(s.labels), strconv.FormatFloat(s.value, 'g', -1, 64))
+		}
+	}
+	b.WriteString("# EOF\n")
+	return b.String()
+}
+
+// tagScenarioCounts counts the scenarios of each tag by status. Tags of a spec apply to all of its scenarios.
+// As in gauge_scenarios, scenarios that only failed with recoverable errors count as failed and
+// scenarios that were not executed are not counted.
+func tagScenarioCounts(res *SuiteResult, with func(...[2]string) [][2]string) *metricFamily {
+	counts := make(map[string]map[string]int)
+	for _, s := range res.SpecResults {
+		if s == nil {
+			continue
+		}
+		for _, scn := range s.Scenarios {
+			if scn.ExecutionStatus == notExecuted {
+				continue
+			}
+			st := allureStatus(scn.ExecutionStatus)
+			seen := make(map[string]bool)
+			for _, t := range append(append([]string{}, s.Tags...), scn.Tags...) {
+				if seen[t] {
+					continue
+				}
+				seen[t] = true
+				if counts[t] == nil {
+					counts[t] = make(map[string]int)
+				}
+				counts[t][st]++
+			}
+		}
+	}
+	tags := make([]string, 0, len(counts))
+	for t := range counts {
+		tags = append(tags, t)
+	}
+	total := func(t string) int { return counts[t]["passed"] + counts[t]["failed"] + counts[t]["skipped"] }
+	sort.Slice(tags, func(i, j int) bool {
+		if total(tags[i]) != total(tags[j]) {
+			return total(tags[i]) > total(tags[j])
+		}
+		return tags[i] < tags[j]
+	})
+	family := &metricFamily{name: "gauge_tag_scenarios", help: "Number of scenarios of each tag by status."}
+	for _, t := range tags {
+		for _, st := range []string{"passed", "failed", "skipped"} {
+			family.series = append(family.series, metricSeries{labels: with([2]string{"tag", t}, [2]string{"status", st}), value: float64(counts[t][st])})
+		}
+	}
+	return family
+}
+
+// limitSeries keeps the first max series of a family, counting the others in dropped.
+func limitSeries(family *metricFamily, max int, dropped *metricFamily, with func(...[2]string) [][2]string) *metricFamily {
+	if len(family.series) > max {
+		dropped.series = append(dropped.series, metricSeries{labels: with([2]string{"metric", family.name}), value: float64(len(family.series) - max)})
+		family.series = family.series[:max]
+	}
+	return family
+}
+
+func formatLabels(labels [][2]string) string {
+	parts := make([]string, 0, len(labels))
+	for _, l := range labels {
+		parts = append(parts, fmt.Sprintf("%s=\"%s\"", l[0], labelValueEscaper.Replace(l[1])))
+	}
+	return strings.Join(parts, ",")
+}
+
+var labelValueEscaper = strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n")
+
+func seconds(ms int64) float64 {
+	return float64(ms) / 1000
+}
+
+// writeFileAtomically writes to a temporary file next to p and renames it to p, so that a reader
+// never sees a partly written file.
+func writeFileAtomically(p string, b []byte) error {
+	dir := filepath.Dir(p)
+	if err := os.MkdirAll(dir, common.NewDirectoryPermissions); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(p)+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), common.NewFilePermissions)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), p)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
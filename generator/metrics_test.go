@@ -0,0 +1,168 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/getgauge/html-report/env"
+	"github.com/getgauge/html-report/model"
+)
+
+func metricsSuiteRes() *SuiteResult {
+	return &SuiteResult{
+		ProjectRoot:      "foo",
+		ProjectName:      "Gauge \"Project\"",
+		Environment:      "ci",
+		ExecutionTime:    122609,
+		SuccessRate:      50,
+		PassedSpecsCount: 1,
+		FailedSpecsCount: 1,
+		Build:            &model.BuildInfo{Commit: "0123456789", Branch: "main"},
+		SpecResults: []*spec{
+			{FileName: filepath.Join("foo", "specs", "fast.spec"), ExecutionTime: 1500, Tags: []string{"smoke"}, PassedScenarioCount: 1,
+				Scenarios: []*scenario{{ExecutionStatus: pass, Tags: []string{"smoke", "words"}}}},
+			{FileName: filepath.Join("foo", "specs", "slow.spec"), ExecutionTime: 60000, FailedScenarioCount: 1,
+				Scenarios: []*scenario{{ExecutionStatus: fail, Tags: []string{"words"}}}},
+		},
+	}
+}
+
+func TestToMetrics(t *testing.T) {
+	suite := `project="Gauge \"Project\"",environment="ci"`
+	want := `# TYPE gauge_suite_duration_seconds gauge
+# UNIT gauge_suite_duration_seconds seconds
+# HELP gauge_suite_duration_seconds Time taken to execute the suite.
+gauge_suite_duration_seconds{` + suite + `} 122.609
+# TYPE gauge_suite_success_ratio gauge
+# HELP gauge_suite_success_ratio Ratio of the specs that passed.
+gauge_suite_success_ratio{` + suite + `} 0.5
+# TYPE gauge_specs gauge
+# HELP gauge_specs Number of specs by status.
+gauge_specs{` + suite + `,status="passed"} 1
+gauge_specs{` + suite + `,status="failed"} 1
+gauge_specs{` + suite + `,status="skipped"} 0
+# TYPE gauge_scenarios gauge
+# HELP gauge_scenarios Number of scenarios by status.
+gauge_scenarios{` + suite + `,status="passed"} 1
+gauge_scenarios{` + suite + `,status="failed"} 1
+gauge_scenarios{` + suite + `,status="skipped"} 0
+# TYPE gauge_build_info gauge
+# HELP gauge_build_info Build the suite was executed for.
+gauge_build_info{` + suite + `,commit="0123456789",branch="main"} 1
+# TYPE gauge_spec_duration_seconds gauge
+# UNIT gauge_spec_duration_seconds seconds
+# HELP gauge_spec_duration_seconds Time taken to execute each spec.
+gauge_spec_duration_seconds{` + suite + `,spec="specs/slow.spec"} 60
+gauge_spec_duration_seconds{` + suite + `,spec="specs/fast.spec"} 1.5
+# TYPE gauge_tag_scenarios gauge
+# HELP gauge_tag_scenarios Number of scenarios of each tag by status.
+gauge_tag_scenarios{` + suite + `,tag="words",status="passed"} 1
+gauge_tag_scenarios{` + suite + `,tag="words",status="failed"} 1
+gauge_tag_scenarios{` + suite + `,tag="words",status="skipped"} 0
+gauge_tag_scenarios{` + suite + `,tag="smoke",status="passed"} 1
+gauge_tag_scenarios{` + suite + `,tag="smoke",status="failed"} 0
+gauge_tag_scenarios{` + suite + `,tag="smoke",status="skipped"} 0
+# EOF
+`
+
+	got := toMetrics(metricsSuiteRes(), metricsOptions{bySpec: true, byTag: true, maxSeries: 100})
+
+	if got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestToMetricsCountsScenariosOfTagsAsScenarios(t *testing.T) {
+	res := metricsSuiteRes()
+	res.SpecResults = append(res.SpecResults, &spec{FileName: filepath.Join("foo", "specs", "soft.spec"), RecoverableScenarioCount: 1, SkippedScenarioCount: 1,
+		Scenarios: []*scenario{{ExecutionStatus: recoverableFail}, {ExecutionStatus: skip}, {ExecutionStatus: notExecuted}}})
+	for _, scn := range res.SpecResults[2].Scenarios {
+		scn.Tags = []string{"soft"}
+	}
+
+	got := toMetrics(res, metricsOptions{byTag: true, maxSeries: 100})
+
+	for _, want := range []string{
+		`gauge_scenarios{project="Gauge \"Project\"",environment="ci",status="failed"} 2`,
+		`gauge_scenarios{project="Gauge \"Project\"",environment="ci",status="skipped"} 1`,
+		`gauge_tag_scenarios{project="Gauge \"Project\"",environment="ci",tag="soft",status="failed"} 1`,
+		`gauge_tag_scenarios{project="Gauge \"Project\"",environment="ci",tag="soft",status="skipped"} 1`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want: %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestToMetricsLimitsCardinality(t *testing.T) {
+
+	got := toMetrics(metricsSuiteRes(), metricsOptions{bySpec: true, byTag: true, maxSeries: 1})
+
+	if strings.Contains(got, `spec="specs/fast.spec"`) || !strings.Contains(got, `spec="specs/slow.spec"`) {
+		t.Errorf("want only the slowest spec, got:\n%s", got)
+	}
+	if strings.Contains(got, `tag="smoke"`) || !strings.Contains(got, `tag="words"`) {
+		t.Errorf("want only the tag with the most scenarios, got:\n%s", got)
+	}
+	for _, want := range []string{`metric="gauge_spec_duration_seconds"} 1`, `metric="gauge_tag_scenarios"} 3`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want: %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestToMetricsWithoutLabels(t *testing.T) {
+	got := toMetrics(metricsSuiteRes(), metricsOptions{maxSeries: 100})
+
+	if strings.Contains(got, "gauge_spec_duration_seconds") || strings.Contains(got, "gauge_tag_scenarios") {
+		t.Errorf("want no metrics by spec or tag, got:\n%s", got)
+	}
+}
+
+func TestMetricsExporterWritesToConfiguredFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, "textfile", "gauge.prom")
+	os.Setenv(env.MetricsFile, p)
+	defer os.Unsetenv(env.MetricsFile)
+	ioutil.WriteFile(filepath.Join(dir, "gauge.prom"), []byte("old"), 0644)
+
+	if err := (&metricsExporter{}).Export(metricsSuiteRes(), dir); err != nil {
+		t.Fatalf("Expected error to be nil. Got: %s", err.Error())
+	}
+
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatalf("Expected %s to be written. Got: %s", p, err.Error())
+	}
+	if !strings.HasSuffix(string(b), "# EOF\n") {
+		t.Errorf("Unexpected metrics: %s", string(b))
+	}
+	files, _ := ioutil.ReadDir(filepath.Dir(p))
+	if len(files) != 1 {
+		t.Errorf("Expected only %s in %s, got %d files", filepath.Base(p), filepath.Dir(p), len(files))
+	}
+}
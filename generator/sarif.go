@@ -0,0 +1,226 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/getgauge/common"
+	"github.com/getgauge/html-report/env"
+	"github.com/getgauge/html-report/model"
+)
+
+const (
+	sarifFormat      = "sarif"
+	sarifFile        = "gauge.sarif"
+	sarifSchema      = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion     = "2.1.0"
+	sarifProjectRoot = "PROJECTROOT"
+)
+
+// sarifRules are the rules results are reported for, in the order they are listed in the log.
+var sarifRules = []sarifRule{
+	{ID: "gauge/parse-error", Name: "ParseError", ShortDescription: sarifMessage{Text: "The spec could not be parsed."}},
+	{ID: "gauge/validation-error", Name: "ValidationError", ShortDescription: sarifMessage{Text: "The spec failed validation, e.g. a step has no implementation."}},
+	{ID: "gauge/assertion-failure", Name: "AssertionFailure", ShortDescription: sarifMessage{Text: "A step failed an assertion."}},
+	{ID: "gauge/verification-failure", Name: "VerificationFailure", ShortDescription: sarifMessage{Text: "A step failed a verification."}},
+	{ID: "gauge/hook-failure", Name: "HookFailure", ShortDescription: sarifMessage{Text: "An execution hook failed."}},
+}
+
+// The sarif* types follow the parts of the SARIF 2.1.0 log format that are written.
+type sarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                    `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLink `json:"originalUriBaseIds"`
+	Results            []*sarifResult               `json:"results"`
+	Properties         *sarifRunProperties          `json:"properties,omitempty"`
+}
+
+type sarifRunProperties struct {
+	Build *model.BuildInfo `json:"build"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifArtifactLink struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLink `json:"artifactLocation"`
+	Region           *sarifRegion      `json:"region,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifExporter writes the parse and validation errors of the specs as a SARIF log, so that code
+// scanning tools and editors can show them on the spec files. Failed steps and hooks are added
+// as results too if html_report_sarif_failures is set.
+type sarifExporter struct {
+}
+
+func (e *sarifExporter) Export(res *SuiteResult, reportsDir string) error {
+	b, err := json.MarshalIndent(toSARIF(res, env.ShouldReportFailuresInSARIF()), "", "  ")
+	if err != nil {
+		return err
+	}
+	p := filepath.Join(reportsDir, sarifFile)
+	if err := ioutil.WriteFile(p, b, common.NewFilePermissions); err != nil {
+		return err
+	}
+	fmt.Printf("Successfully generated sarif report to => %s\n", p)
+	return nil
+}
+
+func toSARIF(res *SuiteResult, withFailures bool) *sarifLog {
+	run := &sarifRun{
+		Tool:               sarifTool{Driver: sarifDriver{Name: "gauge-html-report", InformationURI: "https://github.com/getgauge/html-report", Rules: sarifRules}},
+		OriginalURIBaseIDs: map[string]sarifArtifactLink{sarifProjectRoot: {URI: sarifProjectRootURI(res.ProjectRoot)}},
+		Results:            make([]*sarifResult, 0),
+	}
+	if res.Build != nil {
+		run.Properties = &sarifRunProperties{Build: res.Build}
+	}
+	for _, s := range res.SpecResults {
+		if s == nil {
+			continue
+		}
+		for _, e := range s.Errors {
+			rule := "gauge/validation-error"
+			if e.IsParseError() {
+				rule = "gauge/parse-error"
+			}
+			fileName := e.FileName
+			if fileName == "" {
+				fileName = s.FileName
+			}
+			run.Results = append(run.Results, newSARIFResult(res.ProjectRoot, rule, e.Message, fileName, e.LineNumber))
+		}
+		if withFailures {
+			run.Results = append(run.Results, sarifFailures(res.ProjectRoot, s)...)
+		}
+	}
+	return &sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []*sarifRun{run}}
+}
+
+// sarifFailures returns a result for each failed step and hook of a spec. Steps have no line of
+// their own in the report, so they are located at the start of their scenario.
+func sarifFailures(root string, s *spec) []*sarifResult {
+	var results []*sarifResult
+	hook := func(h *hookFailure, line int) {
+		if h != nil {
+			results = append(results, newSARIFResult(root, "gauge/hook-failure", fmt.Sprintf("%s failed: %s", h.HookName, firstLine(h.ErrMsg)), s.FileName, line))
+		}
+	}
+	for _, h := range s.BeforeSpecHookFailures {
+		hook(h, 0)
+	}
+	for _, scn := range s.Scenarios {
+		line := 0
+		if scn.Span != nil {
+			line = int(scn.Span.Start)
+		}
+		hook(scn.BeforeScenarioHookFailure, line)
+		for _, st := range scn.Steps() {
+			hook(st.BeforeStepHookFailure, line)
+			if st.Result != nil && (st.Result.Status == fail || st.Result.Status == recoverableFail) {
+				rule := "gauge/assertion-failure"
+				if st.Result.ErrorType == verificationErrorType {
+					rule = "gauge/verification-failure"
+				}
+				msg := fmt.Sprintf("Step '%s' of scenario '%s' failed: %s", toStepText(st), scn.Heading, firstLine(st.Result.ErrorMessage))
+				results = append(results, newSARIFResult(root, rule, msg, s.FileName, line))
+			}
+			hook(st.AfterStepHookFailure, line)
+		}
+		hook(scn.AfterScenarioHookFailure, line)
+	}
+	for _, h := range s.AfterSpecHookFailures {
+		hook(h, 0)
+	}
+	return results
+}
+
+func newSARIFResult(root, ruleID, message, fileName string, line int) *sarifResult {
+	r := &sarifResult{RuleID: ruleID, Level: "error", Message: sarifMessage{Text: message}}
+	for i, rule := range sarifRules {
+		if rule.ID == ruleID {
+			r.RuleIndex = i
+		}
+	}
+	artifact := sarifArtifactLink{URI: (&url.URL{Path: relativeSpecPath(root, fileName)}).String(), URIBaseID: sarifProjectRoot}
+	loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: artifact}}
+	if line > 0 {
+		loc.PhysicalLocation.Region = &sarifRegion{StartLine: line}
+	}
+	r.Locations = []sarifLocation{loc}
+	return r
+}
+
+// sarifProjectRootURI is the file url of the project root, which spec paths are relative to.
+// fileURL escapes it, as the relative uris of the results are.
+func sarifProjectRootURI(root string) string {
+	u := fileURL(root)
+	if !strings.HasSuffix(u, "/") {
+		u += "/"
+	}
+	return u
+}
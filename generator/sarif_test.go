@@ -0,0 +1,146 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/getgauge/html-report/env"
+	"github.com/getgauge/html-report/model"
+)
+
+func sarifSuiteRes() *SuiteResult {
+	return &SuiteResult{
+		ProjectRoot: "foo",
+		Build:       &model.BuildInfo{Commit: "0123456789"},
+		SpecResults: []*spec{
+			{
+				FileName: filepath.Join("foo", "specs", "broken.spec"),
+				Errors: []buildError{
+					{ErrorType: parseErrorType, FileName: filepath.Join("foo", "specs", "broken.spec"), LineNumber: 3, Message: "Scenario should have at least one step"},
+					{ErrorType: validationErrorType, LineNumber: 7, Message: "Step implementation not found"},
+				},
+			},
+			{
+				FileName:        filepath.Join("foo", "specs", "failing.spec"),
+				ExecutionStatus: fail,
+				Scenarios: []*scenario{{
+					Heading:         "Count",
+					ExecutionStatus: fail,
+					Span:            &model.Span{Start: 5, End: 9},
+					Items: []item{
+						{Kind: stepKind, Step: &step{
+							Fragments: []*fragment{{FragmentKind: textFragmentKind, Text: "Check count"}},
+							Result:    &result{Status: fail, ErrorType: verificationErrorType, ErrorMessage: "want 2\nstack"},
+						}},
+					},
+					AfterScenarioHookFailure: &hookFailure{HookName: "After Scenario", ErrMsg: "cleanup failed"},
+				}},
+			},
+		},
+	}
+}
+
+func sarifResultLocation(uri string, line int) []sarifLocation {
+	loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLink{URI: uri, URIBaseID: sarifProjectRoot}}}
+	if line > 0 {
+		loc.PhysicalLocation.Region = &sarifRegion{StartLine: line}
+	}
+	return []sarifLocation{loc}
+}
+
+func TestToSARIF(t *testing.T) {
+	want := []*sarifResult{
+		{RuleID: "gauge/parse-error", RuleIndex: 0, Level: "error", Message: sarifMessage{Text: "Scenario should have at least one step"}, Locations: sarifResultLocation("specs/broken.spec", 3)},
+		{RuleID: "gauge/validation-error", RuleIndex: 1, Level: "error", Message: sarifMessage{Text: "Step implementation not found"}, Locations: sarifResultLocation("specs/broken.spec", 7)},
+	}
+
+	got := toSARIF(sarifSuiteRes(), false)
+
+	if got.Version != "2.1.0" || len(got.Runs) != 1 {
+		t.Fatalf("Unexpected log: %+v", got)
+	}
+	checkEqual(t, "results", want, got.Runs[0].Results)
+	checkEqual(t, "build", &sarifRunProperties{Build: &model.BuildInfo{Commit: "0123456789"}}, got.Runs[0].Properties)
+	if root := got.Runs[0].OriginalURIBaseIDs[sarifProjectRoot].URI; root != fileURL("foo")+"/" {
+		t.Errorf("want: %q, got: %q", fileURL("foo")+"/", root)
+	}
+}
+
+func TestToSARIFWithFailures(t *testing.T) {
+	want := []*sarifResult{
+		{RuleID: "gauge/verification-failure", RuleIndex: 3, Level: "error", Message: sarifMessage{Text: "Step 'Check count' of scenario 'Count' failed: want 2"}, Locations: sarifResultLocation("specs/failing.spec", 5)},
+		{RuleID: "gauge/hook-failure", RuleIndex: 4, Level: "error", Message: sarifMessage{Text: "After Scenario failed: cleanup failed"}, Locations: sarifResultLocation("specs/failing.spec", 5)},
+	}
+
+	got := toSARIF(sarifSuiteRes(), true)
+
+	checkEqual(t, "results", want, got.Runs[0].Results[2:])
+}
+
+func TestToSARIFEscapesURIs(t *testing.T) {
+	projectRoot := filepath.Join("my project", "100%")
+	res := &SuiteResult{ProjectRoot: projectRoot, SpecResults: []*spec{{
+		FileName: filepath.Join(projectRoot, "specs", "a #1.spec"),
+		Errors:   []buildError{{ErrorType: parseErrorType, LineNumber: 3, Message: "Scenario should have at least one step"}},
+	}}}
+
+	got := toSARIF(res, false)
+
+	checkEqual(t, "locations", sarifResultLocation("specs/a%20%231.spec", 3), got.Runs[0].Results[0].Locations)
+	root := got.Runs[0].OriginalURIBaseIDs[sarifProjectRoot].URI
+	if !strings.HasSuffix(root, "/my%20project/100%25/") {
+		t.Errorf("want an escaped project root, got: %q", root)
+	}
+}
+
+func TestSARIFExporterWritesLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sarif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv(env.SARIFFailures, "true")
+	defer os.Unsetenv(env.SARIFFailures)
+
+	if err := (&sarifExporter{}).Export(sarifSuiteRes(), dir); err != nil {
+		t.Fatalf("Expected error to be nil. Got: %s", err.Error())
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, sarifFile))
+	if err != nil {
+		t.Fatalf("Expected %s to be written. Got: %s", sarifFile, err.Error())
+	}
+	var log struct {
+		Schema string `json:"$schema"`
+		Runs   []struct {
+			Results []interface{} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(b, &log); err != nil {
+		t.Fatalf("Expected valid json. Got: %s", err.Error())
+	}
+	if log.Schema != sarifSchema || len(log.Runs) != 1 || len(log.Runs[0].Results) != 4 {
+		t.Errorf("Unexpected log: %s", string(b))
+	}
+}
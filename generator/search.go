@@ -0,0 +1,205 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/getgauge/common"
+	"github.com/getgauge/html-report/env"
+	"github.com/getgauge/html-report/model"
+)
+
+const (
+	searchShardsDir  = "search"
+	minTokenLength   = 2
+	maxSearchDocText = 160
+
+	scenarioDoc = "scenario"
+	stepDoc     = "step"
+	errorDoc    = "error"
+	messageDoc  = "message"
+)
+
+// searchShard is the full text index of all specs in a directory.
+// Terms maps each token to the indices of the Docs it occurs in.
+type searchShard struct {
+	Specs [][]string       `json:"Specs"`
+	Docs  []*searchDoc     `json:"Docs"`
+	Terms map[string][]int `json:"Terms"`
+}
+
+// searchDoc is a searchable piece of a spec page. S is the index of the spec in the shard,
+// A is the anchor on the spec page, K the kind of text and T the text shown in search results.
+type searchDoc struct {
+	S int    `json:"s"`
+	A string `json:"a"`
+	K string `json:"k"`
+	T string `json:"t"`
+}
+
+// searchShardRef points to the shard file of a directory. Prefixes are the distinct first
+// minTokenLength characters of the terms in the shard, so that a search only needs to
+// load the shards that can match every word of the query.
+type searchShardRef struct {
+	File     string   `json:"File"`
+	Prefixes []string `json:"Prefixes"`
+}
+
+func newSearchShard() *searchShard {
+	return &searchShard{Specs: make([][]string, 0), Docs: make([]*searchDoc, 0), Terms: make(map[string][]int)}
+}
+
+func (s *searchShard) addSpec(page string, specRes *spec) {
+	specIndex := len(s.Specs)
+	s.Specs = append(s.Specs, []string{page, specRes.SpecHeading})
+	for _, scn := range specRes.Scenarios {
+		s.addDoc(specIndex, scn.Anchor, scenarioDoc, scn.Heading)
+		model.WalkSpec(&spec{Scenarios: []*scenario{scn}}, model.VisitorFuncs{
+			Item: func(_ *scenario, i item) bool {
+				if i.Kind == stepKind {
+					s.addStep(specIndex, i.Step)
+				} else if i.Kind == conceptKind {
+					s.addStep(specIndex, i.Concept.ConceptStep)
+				}
+				return true
+			},
+		})
+	}
+}
+
+func (s *searchShard) addStep(specIndex int, st *step) {
+	s.addDoc(specIndex, st.Anchor, stepDoc, toStepText(st))
+	if st.Result == nil {
+		return
+	}
+	s.addDoc(specIndex, st.Anchor, errorDoc, st.Result.ErrorMessage)
+	for _, m := range st.Result.Messages {
+		s.addDoc(specIndex, st.Anchor, messageDoc, m)
+	}
+}
+
+func (s *searchShard) addDoc(specIndex int, anchor, kind, text string) {
+	tokens := tokenize(text)
+	if len(tokens) == 0 {
+		return
+	}
+	docIndex := len(s.Docs)
+	s.Docs = append(s.Docs, &searchDoc{S: specIndex, A: anchor, K: kind, T: truncate(strings.TrimSpace(text), maxSearchDocText)})
+	for _, t := range tokens {
+		s.Terms[t] = append(s.Terms[t], docIndex)
+	}
+}
+
+// tokenize returns the distinct lower-cased words of text, ignoring words shorter than minTokenLength.
+func tokenize(text string) []string {
+	tokens := make([]string, 0)
+	seen := make(map[string]bool)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		if len([]rune(w)) < minTokenLength || seen[w] {
+			continue
+		}
+		seen[w] = true
+		tokens = append(tokens, w)
+	}
+	return tokens
+}
+
+// prefixes returns the sorted distinct prefixes of the terms in the shard.
+func (s *searchShard) prefixes() []string {
+	seen := make(map[string]bool)
+	prefixes := make([]string, 0)
+	for t := range s.Terms {
+		p := string([]rune(t)[:minTokenLength])
+		if !seen[p] {
+			seen[p] = true
+			prefixes = append(prefixes, p)
+		}
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+func toStepText(st *step) string {
+	parts := make([]string, 0)
+	for _, f := range st.Fragments {
+		switch f.FragmentKind {
+		case textFragmentKind:
+			parts = append(parts, f.Text)
+		case staticFragmentKind, dynamicFragmentKind:
+			parts = append(parts, fmt.Sprintf("%q", f.Text))
+		case specialStringFragmentKind, specialTableFragmentKind:
+			parts = append(parts, "<"+f.Name+">")
+		case tableFragmentKind:
+			parts = append(parts, "<table>")
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
+
+// generateSearchShards writes one full text index per spec directory to js/search and
+// returns the shard of each directory, with its file relative to the js directory.
+func generateSearchShards(suiteRes *SuiteResult, reportsDir string) (map[string]*searchShardRef, error) {
+	shards := make(map[string]*searchShard)
+	for _, r := range suiteRes.SpecResults {
+		page := filepath.ToSlash(toHTMLFileName(r.FileName, suiteRes.ProjectRoot))
+		dir := path.Dir(page)
+		if _, ok := shards[dir]; !ok {
+			shards[dir] = newSearchShard()
+		}
+		shards[dir].addSpec(page, r)
+	}
+	dirs := make([]string, 0, len(shards))
+	for d := range shards {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+	env.CreateDirectory(filepath.Join(reportsDir, "js", searchShardsDir))
+	refs := make(map[string]*searchShardRef)
+	for i, d := range dirs {
+		s, err := json.Marshal(shards[d])
+		if err != nil {
+			return nil, err
+		}
+		f := path.Join(searchShardsDir, fmt.Sprintf("%d.js", i))
+		err = ioutil.WriteFile(filepath.Join(reportsDir, "js", filepath.FromSlash(f)), []byte(fmt.Sprintf("addSearchShard(%q, %s);", f, s)), common.NewFilePermissions)
+		if err != nil {
+			return nil, err
+		}
+		refs[d] = &searchShardRef{File: f, Prefixes: shards[d].prefixes()}
+	}
+	return refs, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	want := []string{"expected", "foo", "but", "was", "bar", "again", "42"}
+
+	got := tokenize("Expected: <foo> but was <BAR>, foo again; a 42")
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want:\n%q\ngot:\n%q\n", want, got)
+	}
+}
+
+func TestToStepText(t *testing.T) {
+	want := `Say "hi" to "gauge" <file:hello.txt><table>`
+
+	got := toStepText(&step{Fragments: []*fragment{
+		{FragmentKind: textFragmentKind, Text: "Say "},
+		{FragmentKind: staticFragmentKind, Text: "hi"},
+		{FragmentKind: textFragmentKind, Text: " to "},
+		{FragmentKind: dynamicFragmentKind, Text: "gauge"},
+		{FragmentKind: textFragmentKind, Text: " "},
+		{FragmentKind: specialStringFragmentKind, Name: "file:hello.txt", Text: "hello"},
+		{FragmentKind: tableFragmentKind, Table: &table{}},
+	}})
+
+	if want != got {
+		t.Errorf("want:\n%q\ngot:\n%q\n", want, got)
+	}
+}
+
+func TestSearchShardIndexesScenariosStepsErrorsAndMessages(t *testing.T) {
+	s := newSearchShard()
+	failing := &step{
+		Anchor:    "step-1",
+		Fragments: []*fragment{{FragmentKind: textFragmentKind, Text: "Open login page"}},
+		Result:    &result{Status: fail, ErrorMessage: "Element not found", Messages: []string{"Took screenshot"}},
+	}
+
+	s.addSpec("login.html", &spec{FileName: "login.spec", SpecHeading: "Login", Scenarios: []*scenario{
+		{Anchor: "scenario-1", Heading: "Invalid password", Items: []item{{Kind: stepKind, Step: failing}}},
+	}})
+
+	wantDocs := []*searchDoc{
+		{S: 0, A: "scenario-1", K: scenarioDoc, T: "Invalid password"},
+		{S: 0, A: "step-1", K: stepDoc, T: "Open login page"},
+		{S: 0, A: "step-1", K: errorDoc, T: "Element not found"},
+		{S: 0, A: "step-1", K: messageDoc, T: "Took screenshot"},
+	}
+	checkEqual(t, "docs", wantDocs, s.Docs)
+	checkEqual(t, "specs", [][]string{{"login.html", "Login"}}, s.Specs)
+	checkEqual(t, "terms", []int{2}, s.Terms["element"])
+	checkEqual(t, "terms", []int{0}, s.Terms["password"])
+}
+
+func TestSearchShardPrefixes(t *testing.T) {
+	s := newSearchShard()
+	s.addDoc(0, "step-1", stepDoc, "Open login page on öffnen")
+
+	checkEqual(t, "prefixes", []string{"lo", "on", "op", "pa", "öf"}, s.prefixes())
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("abcdef", 3); got != "abc…" {
+		t.Errorf("want: abc…, got: %s", got)
+	}
+	if got := truncate("abc", 3); got != "abc" {
+		t.Errorf("want: abc, got: %s", got)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	skippedPageFile  = "skipped.html"
+	noSkipReasonText = "No reason given"
+)
+
+// skippedPage lists the skipped scenarios of a suite, grouped by the reason they were skipped for.
+type skippedPage struct {
+	SuiteRes *SuiteResult
+	Reasons  []*skipReason
+}
+
+type skipReason struct {
+	Reason    string
+	Scenarios []*skippedScenarioLink
+}
+
+// skippedScenarioLink is a skipped scenario and the link to it, relative to the report root.
+type skippedScenarioLink struct {
+	SpecName string
+	Heading  string
+	Link     string
+}
+
+// toSkipReasons groups the skipped scenarios of res by their skip errors, or by the reasons
+// their steps were skipped for if the scenario has none. Reasons are ordered by the number of
+// scenarios skipped for them.
+func toSkipReasons(res *SuiteResult) []*skipReason {
+	byReason := make(map[string]*skipReason)
+	for _, s := range res.SpecResults {
+		if s == nil {
+			continue
+		}
+		for _, scn := range s.Scenarios {
+			if scn.ExecutionStatus != skip {
+				continue
+			}
+			link := filepath.ToSlash(toHTMLFileName(s.FileName, res.ProjectRoot))
+			if scn.Anchor != "" {
+				link += "#" + scn.Anchor
+			}
+			ss := &skippedScenarioLink{SpecName: s.SpecHeading, Heading: scn.Heading, Link: link}
+			for _, r := range scenarioSkipReasons(scn) {
+				if _, ok := byReason[r]; !ok {
+					byReason[r] = &skipReason{Reason: r}
+				}
+				byReason[r].Scenarios = append(byReason[r].Scenarios, ss)
+			}
+		}
+	}
+	reasons := make([]*skipReason, 0, len(byReason))
+	for _, r := range byReason {
+		reasons = append(reasons, r)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if len(reasons[i].Scenarios) != len(reasons[j].Scenarios) {
+			return len(reasons[i].Scenarios) > len(reasons[j].Scenarios)
+		}
+		return reasons[i].Reason < reasons[j].Reason
+	})
+	return reasons
+}
+
+func scenarioSkipReasons(scn *scenario) []string {
+	reasons := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(r string) {
+		if r != "" && !seen[r] {
+			seen[r] = true
+			reasons = append(reasons, r)
+		}
+	}
+	for _, e := range scn.SkipErrors {
+		add(e)
+	}
+	if len(reasons) == 0 {
+		for _, st := range scn.Steps() {
+			if st.Result != nil {
+				add(st.Result.SkippedReason)
+			}
+		}
+	}
+	if len(reasons) == 0 {
+		add(noSkipReasonText)
+	}
+	return reasons
+}
+
+func countSkippedScenarios(res *SuiteResult) int {
+	n := 0
+	for _, s := range res.SpecResults {
+		if s != nil {
+			n += s.SkippedScenarioCount
+		}
+	}
+	return n
+}
+
+// generateSkippedPage writes the page of skipped scenarios grouped by reason. Themes that do not
+// define the skippedPage template get no such page.
+func generateSkippedPage(res *SuiteResult, reportsDir string) error {
+	if parsedTemplates.Lookup("skippedPage") == nil {
+		return nil
+	}
+	reasons := toSkipReasons(res)
+	if len(reasons) == 0 {
+		return nil
+	}
+	return writeFile(filepath.Join(reportsDir, skippedPageFile), func(w io.Writer) {
+		execTemplate("skippedPage", w, &skippedPage{SuiteRes: res, Reasons: reasons})
+	})
+}
@@ -0,0 +1,107 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+
+	"github.com/getgauge/html-report/model"
+)
+
+func skippedScn(heading, anchor string, skipErrors []string, items ...item) *scenario {
+	return &scenario{Heading: heading, Anchor: anchor, ExecutionStatus: skip, SkipErrors: skipErrors, Items: items}
+}
+
+func TestToSkipReasonsGroupsScenariosByReason(t *testing.T) {
+	res := &SuiteResult{ProjectRoot: "foo", SpecResults: []*spec{
+		{SpecHeading: "First", FileName: filepath.Join("foo", "specs", "first.spec"), Scenarios: []*scenario{
+			skippedScn("A", "scenario-1", []string{"Step implementation not found"}),
+			{Heading: "B", ExecutionStatus: pass},
+			skippedScn("C", "", []string{"Tagged for a different platform"}),
+		}},
+		nil,
+		{SpecHeading: "Second", FileName: filepath.Join("foo", "specs", "second.spec"), Scenarios: []*scenario{
+			skippedScn("D", "scenario-4", []string{"Step implementation not found", "Step implementation not found"}),
+		}},
+	}}
+
+	got := toSkipReasons(res)
+
+	want := []*skipReason{
+		{Reason: "Step implementation not found", Scenarios: []*skippedScenarioLink{
+			{SpecName: "First", Heading: "A", Link: "specs/first.html#scenario-1"},
+			{SpecName: "Second", Heading: "D", Link: "specs/second.html#scenario-4"},
+		}},
+		{Reason: "Tagged for a different platform", Scenarios: []*skippedScenarioLink{
+			{SpecName: "First", Heading: "C", Link: "specs/first.html"},
+		}},
+	}
+	checkEqual(t, "", want, got)
+}
+
+func TestScenarioSkipReasonsFallsBackToStepReasons(t *testing.T) {
+	scn := skippedScn("A", "", nil,
+		item{Kind: model.StepKind, Step: &step{Result: &result{Status: skip, SkippedReason: "Dependent step failed"}}},
+		item{Kind: model.StepKind, Step: &step{Result: &result{Status: skip}}},
+	)
+
+	got := scenarioSkipReasons(scn)
+
+	checkEqual(t, "", []string{"Dependent step failed"}, got)
+}
+
+func TestScenarioSkipReasonsWithoutAnyReason(t *testing.T) {
+	got := scenarioSkipReasons(skippedScn("A", "", nil, item{Kind: model.StepKind, Step: &step{Result: &result{Status: skip}}}))
+
+	checkEqual(t, "", []string{noSkipReasonText}, got)
+}
+
+func TestCountSkippedScenarios(t *testing.T) {
+	res := &SuiteResult{SpecResults: []*spec{{SkippedScenarioCount: 2}, nil, {SkippedScenarioCount: 1}}}
+
+	got := countSkippedScenarios(res)
+
+	if got != 3 {
+		t.Errorf("want: %d, got: %d", 3, got)
+	}
+}
+
+func TestGenerateSkippedPageWithoutTemplateInTheme(t *testing.T) {
+	defer func(tmpl *template.Template) { parsedTemplates = tmpl }(parsedTemplates)
+	parsedTemplates = template.Must(template.New("Reports").Parse(`{{define "indexPage"}}{{end}}`))
+	dir, err := ioutil.TempDir("", "skipped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	res := &SuiteResult{SpecResults: []*spec{{Scenarios: []*scenario{skippedScn("A", "scenario-1", []string{"Not implemented"})}}}}
+
+	if err := generateSkippedPage(res, dir); err != nil {
+		t.Errorf("Expected error to be nil. Got: %s", err.Error())
+	}
+	if _, err := os.Stat(filepath.Join(dir, skippedPageFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected no %s for a theme without the skippedPage template", skippedPageFile)
+	}
+	if err := takeTemplateError(); err != nil {
+		t.Errorf("Expected no template error. Got: %s", err.Error())
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/getgauge/html-report/env"
+)
+
+const (
+	defaultSourceCommit = "HEAD"
+	maxSourceFileSize   = 1 << 20
+
+	errorLine         = "error"
+	scenarioLine      = "scenario"
+	scenarioStartLine = "scenario-start"
+)
+
+// toSourceURL fills the configured source url template with the path of fileName relative to the
+// project root of res, the given line and the commit res was executed for. Returns an empty string
+// if no template is configured.
+func toSourceURL(res *SuiteResult, fileName string, line int) string {
+	tmpl := env.GetSourceURLTemplate()
+	if tmpl == "" || fileName == "" {
+		return ""
+	}
+	if line < 1 {
+		line = 1
+	}
+	commit := defaultSourceCommit
+	if res.Build != nil && res.Build.Commit != "" {
+		commit = res.Build.Commit
+	}
+	r := strings.NewReplacer(
+		"{commit}", commit,
+		"{path}", toSourcePath(res.ProjectRoot, fileName),
+		"{line}", strconv.Itoa(line),
+	)
+	return r.Replace(tmpl)
+}
+
+// toSourcePath returns the url escaped path of fileName relative to root.
+func toSourcePath(root, fileName string) string {
+	segments := strings.Split(relativeSpecPath(root, fileName), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// sourceView is the source of a spec file as shown in the Source tab of a spec page.
+// Active is set when the tab should be shown instead of the scenarios.
+type sourceView struct {
+	Active bool
+	Lines  []*sourceLine
+}
+
+// sourceLine is a line of a spec file. Class marks error lines and the lines of scenarios,
+// Anchor is set on the first line of a scenario, Title holds the errors reported for the line
+// and Status the result of the step on the line.
+type sourceLine struct {
+	Number int
+	Text   string
+	Class  string
+	Anchor string
+	Title  string
+	Status status
+}
+
+var teardownSeparator = regexp.MustCompile(`^_{3,}\s*$`)
+
+// toSourceView reads the spec file of s, returning nil if the Source tab is disabled
+// or the file cannot be read.
+func toSourceView(s *spec) *sourceView {
+	if !env.ShouldShowSource() || s.FileName == "" {
+		return nil
+	}
+	fi, err := os.Stat(s.FileName)
+	if err != nil || fi.Size() > maxSourceFileSize {
+		return nil
+	}
+	b, err := ioutil.ReadFile(s.FileName)
+	if err != nil {
+		return nil
+	}
+	text := strings.TrimSuffix(strings.Replace(string(b), "\r\n", "\n", -1), "\n")
+	v := &sourceView{Active: containsParseErrors(s.Errors), Lines: make([]*sourceLine, 0)}
+	for i, l := range strings.Split(text, "\n") {
+		v.Lines = append(v.Lines, &sourceLine{Number: i + 1, Text: l})
+	}
+	v.markScenarios(s)
+	v.markErrors(s)
+	return v
+}
+
+func (v *sourceView) line(n int) *sourceLine {
+	if n < 1 || n > len(v.Lines) {
+		return nil
+	}
+	return v.Lines[n-1]
+}
+
+func (v *sourceView) markErrors(s *spec) {
+	for _, e := range s.Errors {
+		l := v.line(e.LineNumber)
+		if l == nil || (e.FileName != "" && e.FileName != s.FileName) {
+			continue
+		}
+		l.Class = errorLine
+		if l.Title != "" {
+			l.Title += "\n"
+		}
+		l.Title += e.Error()
+	}
+}
+
+// markScenarios highlights the lines of each scenario and marks the step lines with the
+// result of their step. As steps carry no line numbers, the step lines of a scenario are
+// matched to its steps in order; scenarios whose steps do not match are left unmarked.
+// Context steps are the ones before the first scenario, teardown steps the ones after the
+// teardown separator.
+func (v *sourceView) markScenarios(s *spec) {
+	first, teardown := len(v.Lines)+1, len(v.Lines)+1
+	for _, l := range v.Lines {
+		if teardownSeparator.MatchString(l.Text) {
+			teardown = l.Number
+		}
+	}
+	for _, scn := range s.Scenarios {
+		if scn.Span == nil {
+			continue
+		}
+		start, end := int(scn.Span.Start), int(scn.Span.End)
+		if start < first {
+			first = start
+		}
+		for n := start; n <= end; n++ {
+			if l := v.line(n); l != nil && l.Class == "" {
+				l.Class = scenarioLine
+			}
+		}
+		if l := v.line(start); l != nil {
+			l.Class, l.Anchor = scenarioStartLine, scn.Anchor
+		}
+		v.markSteps(v.stepLines(start, end), scn.Items)
+	}
+	if first > len(v.Lines) {
+		return
+	}
+	for _, scn := range s.Scenarios {
+		v.markSteps(v.stepLines(1, first-1), scn.Contexts)
+		v.markSteps(v.stepLines(teardown+1, len(v.Lines)), scn.Teardowns)
+	}
+}
+
+func (v *sourceView) stepLines(from, to int) []*sourceLine {
+	lines := make([]*sourceLine, 0)
+	for n := from; n <= to; n++ {
+		if l := v.line(n); l != nil && strings.HasPrefix(strings.TrimSpace(l.Text), "*") {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+func (v *sourceView) markSteps(lines []*sourceLine, items []item) {
+	results := make([]*result, 0)
+	for _, i := range items {
+		if i.Kind == stepKind {
+			results = append(results, i.Step.Result)
+		} else if i.Kind == conceptKind {
+			results = append(results, i.Concept.ConceptStep.Result)
+		}
+	}
+	if len(results) != len(lines) {
+		return
+	}
+	for i, r := range results {
+		if r != nil && statusSeverity[r.Status] > statusSeverity[lines[i].Status] {
+			lines[i].Status = r.Status
+		}
+	}
+}
+
+// statusSeverity orders step statuses, so that a step line shared by the scenarios of all
+// table rows shows the worst result.
+var statusSeverity = map[status]int{pass: 1, skip: 2, recoverableFail: 3, fail: 4}
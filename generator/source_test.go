@@ -0,0 +1,238 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getgauge/html-report/env"
+	gm "github.com/getgauge/html-report/gauge_messages"
+	"github.com/getgauge/html-report/model"
+)
+
+func TestToSourceURLWithoutTemplate(t *testing.T) {
+	os.Unsetenv(env.SourceURL)
+
+	got := toSourceURL(&SuiteResult{ProjectRoot: "foo"}, filepath.Join("foo", "specs", "example.spec"), 3)
+
+	if got != "" {
+		t.Errorf("Expected no source url, got %q", got)
+	}
+}
+
+func TestToSourceURL(t *testing.T) {
+	os.Setenv(env.SourceURL, "https://git.example/repo/blob/{commit}/{path}#L{line}")
+	defer os.Unsetenv(env.SourceURL)
+	res := &SuiteResult{ProjectRoot: "foo"}
+
+	tests := []struct {
+		fileName string
+		line     int
+		want     string
+	}{
+		{filepath.Join("foo", "specs", "example.spec"), 12, "https://git.example/repo/blob/HEAD/specs/example.spec#L12"},
+		{filepath.Join("foo", "specs", "my spec.spec"), 0, "https://git.example/repo/blob/HEAD/specs/my%20spec.spec#L1"},
+		{filepath.Join("bar", "example.spec"), 2, "https://git.example/repo/blob/HEAD/../bar/example.spec#L2"},
+	}
+	for _, test := range tests {
+		got := toSourceURL(res, test.fileName, test.line)
+		if got != test.want {
+			t.Errorf("want: %q, got: %q", test.want, got)
+		}
+	}
+}
+
+func TestToSuiteResultWithBuildLinksSourceOfBuildCommit(t *testing.T) {
+	os.Setenv(env.SourceURL, "https://git.example/{commit}/{path}")
+	defer os.Unsetenv(env.SourceURL)
+	build := &model.BuildInfo{Commit: "0123456789"}
+	psr := &gm.ProtoSuiteResult{SpecResults: []*gm.ProtoSpecResult{
+		{ProtoSpec: &gm.ProtoSpec{SpecHeading: "Example", FileName: filepath.Join("foo", "example.spec")}},
+	}}
+
+	res := ToSuiteResultWithBuild("foo", psr, build)
+
+	if res.Build != build {
+		t.Errorf("want: %+v, got: %+v", build, res.Build)
+	}
+	want := "https://git.example/0123456789/example.spec"
+	if got := res.SpecResults[0].SourceURL; got != want {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+	if other := ToSuiteResultWithBuild("bar", psr, &model.BuildInfo{Commit: "fedcba"}); res.SpecResults[0].SourceURL != want || res.ProjectRoot != "foo" || other.ProjectRoot != "bar" {
+		t.Errorf("Expected converting another result to leave the first one as is, got: %q", res.SpecResults[0].SourceURL)
+	}
+	if res = ToSuiteResult("foo", psr); res.Build != nil {
+		t.Errorf("Expected no build, got: %+v", res.Build)
+	}
+}
+
+func TestToSuiteResultLinksScenariosToSourceLines(t *testing.T) {
+	os.Setenv(env.SourceURL, "https://git.example/{path}#L{line}")
+	defer os.Unsetenv(env.SourceURL)
+	res := &gm.ProtoSpecResult{
+		ProtoSpec: &gm.ProtoSpec{
+			FileName: filepath.Join("foo", "example.spec"),
+			Items: []*gm.ProtoItem{
+				newScenarioItem(&gm.ProtoScenario{ScenarioHeading: "with span", Span: &gm.Span{Start: 4, End: 8}}),
+				newScenarioItem(&gm.ProtoScenario{ScenarioHeading: "without span"}),
+			},
+		},
+	}
+
+	got := ToSuiteResult("foo", &gm.ProtoSuiteResult{SpecResults: []*gm.ProtoSpecResult{res}}).SpecResults[0]
+
+	urls := map[string]string{}
+	for _, scn := range got.Scenarios {
+		urls[scn.Heading] = scn.SourceURL
+	}
+	if urls["with span"] != "https://git.example/example.spec#L4" {
+		t.Errorf("want: %q, got: %q", "https://git.example/example.spec#L4", urls["with span"])
+	}
+	if urls["without span"] != "" {
+		t.Errorf("Expected no source url for scenario without span, got %q", urls["without span"])
+	}
+	if h := toSpecHeader(got); h.SourceURL != "https://git.example/example.spec#L1" {
+		t.Errorf("want: %q, got: %q", "https://git.example/example.spec#L1", h.SourceURL)
+	}
+}
+
+const exampleSpec = `# Example
+
+* Context step
+
+## First scenario
+* Step one
+* Step two
+
+## Second scenario
+* Step one
+
+___
+* Teardown step
+`
+
+func writeSpecFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "example.spec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func stepWithStatus(s status) item {
+	return item{Kind: stepKind, Step: &step{Result: &result{Status: s}}}
+}
+
+func TestToSourceViewWhenDisabled(t *testing.T) {
+	os.Unsetenv(env.ShowSource)
+	p := writeSpecFile(t, exampleSpec)
+	defer os.Remove(p)
+
+	if got := toSourceView(&spec{FileName: p}); got != nil {
+		t.Errorf("Expected no source view, got %v", got)
+	}
+}
+
+func TestToSourceViewWithMissingFile(t *testing.T) {
+	os.Setenv(env.ShowSource, "true")
+	defer os.Unsetenv(env.ShowSource)
+
+	if got := toSourceView(&spec{FileName: filepath.Join("_testdata", "missing.spec")}); got != nil {
+		t.Errorf("Expected no source view, got %v", got)
+	}
+}
+
+func TestToSourceViewMarksScenariosAndSteps(t *testing.T) {
+	os.Setenv(env.ShowSource, "true")
+	defer os.Unsetenv(env.ShowSource)
+	p := writeSpecFile(t, exampleSpec)
+	defer os.Remove(p)
+	s := &spec{
+		FileName: p,
+		Scenarios: []*scenario{
+			{
+				Anchor:    "scenario-5",
+				Span:      &model.Span{Start: 5, End: 8},
+				Contexts:  []item{stepWithStatus(pass)},
+				Items:     []item{stepWithStatus(pass), stepWithStatus(fail)},
+				Teardowns: []item{stepWithStatus(pass)},
+			},
+			{
+				Anchor:    "scenario-9",
+				Span:      &model.Span{Start: 9, End: 11},
+				Contexts:  []item{stepWithStatus(pass)},
+				Items:     []item{stepWithStatus(skip), {Kind: commentKind, Comment: &comment{Text: "extra"}}},
+				Teardowns: []item{stepWithStatus(fail)},
+			},
+		},
+	}
+
+	got := toSourceView(s)
+
+	if got == nil || len(got.Lines) != 13 || got.Active {
+		t.Fatalf("Expected an inactive source view of 13 lines, got %v", got)
+	}
+	want := []struct {
+		class  string
+		anchor string
+		status status
+	}{
+		{"", "", ""}, {"", "", ""}, {"", "", pass}, {"", "", ""},
+		{scenarioStartLine, "scenario-5", ""}, {scenarioLine, "", pass}, {scenarioLine, "", fail}, {scenarioLine, "", ""},
+		{scenarioStartLine, "scenario-9", ""}, {scenarioLine, "", skip}, {scenarioLine, "", ""},
+		{"", "", ""}, {"", "", fail},
+	}
+	for i, w := range want {
+		l := got.Lines[i]
+		if l.Number != i+1 || l.Class != w.class || l.Anchor != w.anchor || l.Status != w.status {
+			t.Errorf("line %d - want: %q %q %q, got: %d %q %q %q", i+1, w.class, w.anchor, w.status, l.Number, l.Class, l.Anchor, l.Status)
+		}
+	}
+}
+
+func TestToSourceViewHighlightsParseErrors(t *testing.T) {
+	os.Setenv(env.ShowSource, "true")
+	defer os.Unsetenv(env.ShowSource)
+	p := writeSpecFile(t, "# Example\r\n\r\n## Scenario\r\n")
+	defer os.Remove(p)
+	s := &spec{FileName: p, Errors: []buildError{
+		{ErrorType: parseErrorType, FileName: p, LineNumber: 3, Message: "Scenario should have atleast one step"},
+		{ErrorType: parseErrorType, FileName: "other.cpt", LineNumber: 1, Message: "in another file"},
+		{ErrorType: parseErrorType, FileName: p, LineNumber: 42, Message: "out of range"},
+	}}
+
+	got := toSourceView(s)
+
+	if got == nil || !got.Active || len(got.Lines) != 3 {
+		t.Fatalf("Expected an active source view of 3 lines, got %v", got)
+	}
+	if got.Lines[2].Text != "## Scenario" || got.Lines[2].Class != errorLine || got.Lines[2].Title != "[Parse Error] Scenario should have atleast one step" {
+		t.Errorf("Expected line 3 to be highlighted, got %+v", got.Lines[2])
+	}
+	if got.Lines[0].Class != "" {
+		t.Errorf("Expected errors of other files to be ignored, got %+v", got.Lines[0])
+	}
+}
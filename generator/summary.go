@@ -0,0 +1,212 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// SummaryStyle tells how a console summary is rendered.
+type SummaryStyle int
+
+const (
+	// PlainSummary renders the summary as plain text.
+	PlainSummary SummaryStyle = iota
+	// ANSISummary colors the summary with ANSI escape codes.
+	ANSISummary
+)
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiGray  = "\x1b[90m"
+)
+
+type summaryWriter struct {
+	w     io.Writer
+	style SummaryStyle
+	err   error
+}
+
+func (s *summaryWriter) printf(format string, a ...interface{}) {
+	if s.err == nil {
+		_, s.err = fmt.Fprintf(s.w, format, a...)
+	}
+}
+
+func (s *summaryWriter) color(code, text string) string {
+	if s.style != ANSISummary || text == "" {
+		return text
+	}
+	return code + text + ansiReset
+}
+
+// WriteSummary writes the totals of res to w, followed by the hook failures of the suite and the
+// failed specs and scenarios with the first line of their error and a link to them in the html
+// report generated in reportsDir. Links are left out if reportsDir is empty.
+func WriteSummary(w io.Writer, res *SuiteResult, reportsDir string, style SummaryStyle) error {
+	s := &summaryWriter{w: w, style: style}
+	s.printf("%s\n", s.color(ansiBold, strings.Join(nonEmpty(res.ProjectName, res.Environment, res.Timestamp), " | ")))
+	s.printf("Specifications: %s\n", s.counts(res.PassedSpecsCount, res.FailedSpecsCount, res.SkippedSpecsCount))
+	p, f, sk := countScenarios(res)
+	s.printf("Scenarios:      %s\n", s.counts(p, f, sk))
+	s.printf("Success rate:   %.2f%%\n", res.SuccessRate)
+	s.printf("Duration:       %s\n", formatTime(res.ExecutionTime))
+
+	suiteHooks := nonNilHookFailures(res.BeforeSuiteHookFailure, res.AfterSuiteHookFailure)
+	if len(suiteHooks) > 0 {
+		s.printf("\n%s\n", s.color(ansiBold, "Hook failures:"))
+		for _, h := range suiteHooks {
+			s.printf("  %s %s: %s\n", s.color(ansiRed, "✗"), h.HookName, firstLine(h.ErrMsg))
+		}
+	}
+
+	var failed []*spec
+	for _, specRes := range res.SpecResults {
+		if specRes != nil && specRes.ExecutionStatus == fail {
+			failed = append(failed, specRes)
+		}
+	}
+	if len(failed) > 0 {
+		s.printf("\n%s\n", s.color(ansiBold, "Failed:"))
+	}
+	for _, specRes := range failed {
+		page := toHTMLFileName(specRes.FileName, res.ProjectRoot)
+		s.printf("  %s\n", strings.Join(nonEmpty(s.color(ansiRed, "✗"), specRes.SpecHeading, s.color(ansiGray, reportLink(reportsDir, page, ""))), " "))
+		for _, e := range specRes.Errors {
+			s.printf("      %s\n", firstLine(e.Error()))
+		}
+		for _, h := range append(append([]*hookFailure{}, specRes.BeforeSpecHookFailures...), specRes.AfterSpecHookFailures...) {
+			s.printf("      %s: %s\n", h.HookName, firstLine(h.ErrMsg))
+		}
+		for _, scn := range specRes.Scenarios {
+			if scn.ExecutionStatus != fail && scn.ExecutionStatus != recoverableFail {
+				continue
+			}
+			s.printf("    %s %s\n", s.color(ansiRed, "✗"), scn.Heading)
+			if msg := firstScenarioError(scn); msg != "" {
+				s.printf("      %s\n", firstLine(msg))
+			}
+			if link := reportLink(reportsDir, page, scn.Anchor); link != "" {
+				s.printf("      %s\n", s.color(ansiGray, link))
+			}
+		}
+	}
+	return s.err
+}
+
+// counts lists the passed, failed and skipped items. As in the summary of Gauge, skipped items do not
+// count as executed.
+func (s *summaryWriter) counts(passed, failed, skipped int) string {
+	return fmt.Sprintf("%d executed, %s, %s, %s", passed+failed,
+		s.color(ansiGreen, fmt.Sprintf("%d passed", passed)),
+		s.color(ansiRed, fmt.Sprintf("%d failed", failed)),
+		s.color(ansiGray, fmt.Sprintf("%d skipped", skipped)))
+}
+
+// countScenarios totals the scenarios of res. Scenarios that only failed with recoverable errors
+// count as failed.
+func countScenarios(res *SuiteResult) (passed, failed, skipped int) {
+	for _, s := range res.SpecResults {
+		if s == nil {
+			continue
+		}
+		passed += s.PassedScenarioCount
+		failed += s.FailedScenarioCount + s.RecoverableScenarioCount
+		skipped += s.SkippedScenarioCount
+	}
+	return
+}
+
+// firstScenarioError returns the error a scenario failed with: the failure of its before
+// scenario hook, of its first failing step or of its after scenario hook.
+func firstScenarioError(scn *scenario) string {
+	if scn.BeforeScenarioHookFailure != nil {
+		return scn.BeforeScenarioHookFailure.ErrMsg
+	}
+	for _, st := range scn.Steps() {
+		if st.BeforeStepHookFailure != nil {
+			return st.BeforeStepHookFailure.ErrMsg
+		}
+		if st.Result != nil && (st.Result.Status == fail || st.Result.Status == recoverableFail) {
+			return st.Result.ErrorMessage
+		}
+		if st.AfterStepHookFailure != nil {
+			return st.AfterStepHookFailure.ErrMsg
+		}
+	}
+	if scn.AfterScenarioHookFailure != nil {
+		return scn.AfterScenarioHookFailure.ErrMsg
+	}
+	return ""
+}
+
+// reportLink returns a file url to a page of the report in reportsDir, optionally to an anchor on it.
+// Returns an empty string if reportsDir is empty.
+func reportLink(reportsDir, page, anchor string) string {
+	if reportsDir == "" {
+		return ""
+	}
+	link := fileURL(filepath.Join(reportsDir, page))
+	if anchor != "" {
+		link += "#" + anchor
+	}
+	return link
+}
+
+// fileURL returns the file url of a path, made absolute.
+func fileURL(p string) string {
+	if abs, err := filepath.Abs(p); err == nil {
+		p = abs
+	}
+	p = filepath.ToSlash(p)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+func firstLine(s string) string {
+	return strings.TrimSpace(strings.SplitN(strings.TrimSpace(s), "\n", 2)[0])
+}
+
+func nonEmpty(values ...string) []string {
+	var r []string
+	for _, v := range values {
+		if v != "" {
+			r = append(r, v)
+		}
+	}
+	return r
+}
+
+func nonNilHookFailures(hooks ...*hookFailure) []*hookFailure {
+	var r []*hookFailure
+	for _, h := range hooks {
+		if h != nil {
+			r = append(r, h)
+		}
+	}
+	return r
+}
@@ -0,0 +1,161 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package generator
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func summarySuiteRes() *SuiteResult {
+	failing := &scenario{
+		Heading:         "Failing scenario",
+		ExecutionStatus: fail,
+		Anchor:          "scenario-failing",
+		Items: []item{
+			{Kind: stepKind, Step: &step{Result: &result{Status: pass}}},
+			{Kind: stepKind, Step: &step{Result: &result{Status: fail, ErrorMessage: "expected 1 to equal 2\nat step.go:1"}}},
+		},
+	}
+	hookFailing := &scenario{
+		Heading:                   "Hook failing scenario",
+		ExecutionStatus:           fail,
+		Anchor:                    "scenario-hook",
+		BeforeScenarioHookFailure: &hookFailure{HookName: "Before Scenario", ErrMsg: "no database"},
+	}
+	return &SuiteResult{
+		ProjectRoot:           "foo",
+		ProjectName:           "Gauge Project",
+		Environment:           "default",
+		SuccessRate:           50,
+		ExecutionTime:         122609,
+		PassedSpecsCount:      1,
+		FailedSpecsCount:      1,
+		AfterSuiteHookFailure: &hookFailure{HookName: "After Suite", ErrMsg: "cleanup failed"},
+		SpecResults: []*spec{
+			{SpecHeading: "Passing", FileName: filepath.Join("foo", "specs", "passing.spec"), ExecutionStatus: pass, PassedScenarioCount: 1,
+				Scenarios: []*scenario{{Heading: "Passing scenario", ExecutionStatus: pass}}},
+			{SpecHeading: "Failing", FileName: filepath.Join("foo", "specs", "failing.spec"), ExecutionStatus: fail, FailedScenarioCount: 2, SkippedScenarioCount: 1,
+				Scenarios: []*scenario{failing, hookFailing, {Heading: "Skipped scenario", ExecutionStatus: skip}}},
+		},
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	reportsDir, _ := filepath.Abs("reports")
+	link := "file://" + filepath.ToSlash(filepath.Join(reportsDir, "specs", "failing.html"))
+	if !strings.HasPrefix(link, "file:///") {
+		link = "file:///" + strings.TrimPrefix(link, "file://")
+	}
+	want := `Gauge Project | default
+Specifications: 2 executed, 1 passed, 1 failed, 0 skipped
+Scenarios:      3 executed, 1 passed, 2 failed, 1 skipped
+Success rate:   50.00%
+Duration:       00:02:02
+
+Hook failures:
+  ✗ After Suite: cleanup failed
+
+Failed:
+  ✗ Failing ` + link + `
+    ✗ Failing scenario
+      expected 1 to equal 2
+      ` + link + `#scenario-failing
+    ✗ Hook failing scenario
+      no database
+      ` + link + `#scenario-hook
+`
+	var b bytes.Buffer
+
+	err := WriteSummary(&b, summarySuiteRes(), "reports", PlainSummary)
+
+	if err != nil {
+		t.Fatalf("Expected error to be nil. Got: %s", err.Error())
+	}
+	if got := b.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestWriteSummaryWithANSIColors(t *testing.T) {
+	var b bytes.Buffer
+
+	WriteSummary(&b, summarySuiteRes(), "reports", ANSISummary)
+
+	got := b.String()
+	for _, want := range []string{ansiBold + "Gauge Project | default" + ansiReset, ansiGreen + "1 passed" + ansiReset, ansiRed + "✗" + ansiReset + " Failing scenario"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want: %q in %q", want, got)
+		}
+	}
+}
+
+func TestWriteSummaryOfPassingSuite(t *testing.T) {
+	var b bytes.Buffer
+	res := &SuiteResult{ProjectName: "Gauge Project", PassedSpecsCount: 1, SuccessRate: 100, SpecResults: []*spec{{ExecutionStatus: pass, PassedScenarioCount: 1}}}
+
+	WriteSummary(&b, res, "reports", PlainSummary)
+
+	if got := b.String(); strings.Contains(got, "Failed:") || strings.Contains(got, "Hook failures:") {
+		t.Errorf("want no failures listed, got: %q", got)
+	}
+}
+
+func TestWriteSummaryCountsRecoverableScenariosAsFailed(t *testing.T) {
+	recoverable := &scenario{
+		Heading:         "Recoverable scenario",
+		ExecutionStatus: recoverableFail,
+		Items:           []item{{Kind: stepKind, Step: &step{Result: &result{Status: recoverableFail, ErrorMessage: "soft assertion failed"}}}},
+	}
+	res := &SuiteResult{FailedSpecsCount: 1, SpecResults: []*spec{
+		{SpecHeading: "Failing", ExecutionStatus: fail, PassedScenarioCount: 1, RecoverableScenarioCount: 1,
+			Scenarios: []*scenario{{Heading: "Passing scenario", ExecutionStatus: pass}, recoverable}},
+	}}
+	var b bytes.Buffer
+
+	WriteSummary(&b, res, "", PlainSummary)
+
+	got := b.String()
+	for _, want := range []string{"Scenarios:      2 executed, 1 passed, 1 failed, 0 skipped", "✗ Recoverable scenario\n      soft assertion failed\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want: %q in %q", want, got)
+		}
+	}
+}
+
+func TestWriteSummaryWithoutReportsDirHasNoLinks(t *testing.T) {
+	var b bytes.Buffer
+
+	WriteSummary(&b, summarySuiteRes(), "", PlainSummary)
+
+	if got := b.String(); strings.Contains(got, "file://") || !strings.Contains(got, "  ✗ Failing\n") {
+		t.Errorf("want failures without links, got: %q", got)
+	}
+}
+
+func TestReportLinkEscapesPath(t *testing.T) {
+	reportsDir, _ := filepath.Abs(filepath.Join("reports", "2006-01-02 15.04.05"))
+
+	got := reportLink(reportsDir, filepath.Join("specs", "a#b.html"), "scenario-1")
+
+	if !strings.HasPrefix(got, "file:///") || !strings.HasSuffix(got, "/reports/2006-01-02%2015.04.05/specs/a%23b.html#scenario-1") {
+		t.Errorf("want an escaped file url, got: %q", got)
+	}
+}
@@ -19,14 +19,18 @@ package generator
 
 import (
 	"encoding/base64"
+	"fmt"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"path"
 
 	gm "github.com/getgauge/html-report/gauge_messages"
+	"github.com/getgauge/html-report/model"
 )
 
 const (
@@ -36,7 +40,12 @@ const (
 
 // ToSuiteResult Converts the ProtoSuiteResult to SuiteResult type.
 func ToSuiteResult(pRoot string, psr *gm.ProtoSuiteResult) *SuiteResult {
-	projectRoot = pRoot
+	return ToSuiteResultWithBuild(pRoot, psr, nil)
+}
+
+// ToSuiteResultWithBuild converts the ProtoSuiteResult to SuiteResult type for a suite executed
+// for the given build. Source links point to the commit of the build.
+func ToSuiteResultWithBuild(pRoot string, psr *gm.ProtoSuiteResult, build *model.BuildInfo) *SuiteResult {
 	suiteResult := SuiteResult{
 		ProjectName:            psr.GetProjectName(),
 		Environment:            psr.GetEnvironment(),
@@ -50,6 +59,8 @@ func ToSuiteResult(pRoot string, psr *gm.ProtoSuiteResult) *SuiteResult {
 		SuccessRate:            psr.GetSuccessRate(),
 		Timestamp:              psr.GetTimestamp(),
 		ExecutionStatus:        pass,
+		Build:                  build,
+		ProjectRoot:            pRoot,
 	}
 	if psr.GetFailed() {
 		suiteResult.ExecutionStatus = fail
@@ -58,9 +69,25 @@ func ToSuiteResult(pRoot string, psr *gm.ProtoSuiteResult) *SuiteResult {
 	for _, protoSpecRes := range psr.GetSpecResults() {
 		suiteResult.SpecResults = append(suiteResult.SpecResults, toSpec(protoSpecRes))
 	}
+	linkSources(&suiteResult)
 	return &suiteResult
 }
 
+// linkSources links the specs of res, their scenarios and their errors to their source.
+func linkSources(res *SuiteResult) {
+	for _, s := range res.SpecResults {
+		s.SourceURL = toSourceURL(res, s.FileName, 1)
+		for i, e := range s.Errors {
+			s.Errors[i].SourceURL = toSourceURL(res, e.FileName, e.LineNumber)
+		}
+		for _, scn := range s.Scenarios {
+			if scn.Span != nil {
+				scn.SourceURL = toSourceURL(res, s.FileName, int(scn.Span.Start))
+			}
+		}
+	}
+}
+
 func toNestedSuiteResult(basePath string, result *SuiteResult) *SuiteResult {
 	sr := &SuiteResult{
 		ProjectName: result.ProjectName,
@@ -70,8 +97,10 @@ func toNestedSuiteResult(basePath string, result *SuiteResult) *SuiteResult {
 		BeforeSuiteHookFailure: result.BeforeSuiteHookFailure,
 		AfterSuiteHookFailure:  result.AfterSuiteHookFailure,
 		ExecutionStatus:        pass,
-		SpecResults:            getNestedSpecResults(result.SpecResults, basePath),
+		SpecResults:            getNestedSpecResults(result.SpecResults, result.ProjectRoot, basePath),
 		BasePath:               filepath.Clean(basePath),
+		Build:                  result.Build,
+		ProjectRoot:            result.ProjectRoot,
 	}
 
 	for _, spec := range sr.SpecResults {
@@ -98,10 +127,10 @@ func getSuccessRate(totalSpecs int, failedSpecs int) float32 {
 	return (float32)(100.0 * (totalSpecs - failedSpecs) / totalSpecs)
 }
 
-func getNestedSpecResults(specResults []*spec, basePath string) []*spec {
+func getNestedSpecResults(specResults []*spec, root, basePath string) []*spec {
 	nestedSpecResults := make([]*spec, 0)
 	for _, specResult := range specResults {
-		rel, _ := filepath.Rel(projectRoot, specResult.FileName)
+		rel := filepath.FromSlash(relativeSpecPath(root, specResult.FileName))
 		if strings.HasPrefix(rel, basePath) {
 			nestedSpecResults = append(nestedSpecResults, specResult)
 		}
@@ -116,10 +145,10 @@ func toOverview(res *SuiteResult, filePath string) *overview {
 	}
 	base := ""
 	if filePath != "" {
-		base, _ = filepath.Rel(filepath.Dir(filePath), projectRoot)
+		base, _ = filepath.Rel(filepath.Dir(filePath), res.ProjectRoot)
 		base = path.Join(base, "/")
 	} else if res.BasePath != "" {
-		base, _ = filepath.Rel(filepath.Join(projectRoot, res.BasePath), projectRoot)
+		base, _ = filepath.Rel(filepath.Join(res.ProjectRoot, res.BasePath), res.ProjectRoot)
 		base = path.Join(base, "/")
 	}
 	return &overview{
@@ -131,9 +160,66 @@ func toOverview(res *SuiteResult, filePath string) *overview {
 		Timestamp:     res.Timestamp,
 		Summary:       &summary{Failed: res.FailedSpecsCount, Total: totalSpecs, Passed: res.PassedSpecsCount, Skipped: res.SkippedSpecsCount},
 		BasePath:      base,
+		Build:         res.Build,
+		ErrorTypes:    countErrorTypes(res.SpecResults),
+		Skipped:       countSkippedScenarios(res),
 	}
 }
 
+// errorTypes are the error types of step failures, in the order they are reported.
+var errorTypes = []errorType{assertionErrorType, verificationErrorType}
+
+// countErrorTypes counts the failed steps of each error type. Concept steps are not
+// counted, as their failure is the one of a step in the concept.
+func countErrorTypes(specs []*spec) []*errorTypeCount {
+	counts := make(map[errorType]int)
+	for _, s := range specs {
+		for t, n := range failedErrorTypes(s) {
+			counts[t] += n
+		}
+	}
+	var res []*errorTypeCount
+	for _, t := range errorTypes {
+		if counts[t] > 0 {
+			res = append(res, &errorTypeCount{Type: t, Count: counts[t]})
+		}
+	}
+	return res
+}
+
+func failedErrorTypes(s *spec) map[errorType]int {
+	counts := make(map[errorType]int)
+	model.WalkSpec(s, model.VisitorFuncs{
+		Item: func(_ *scenario, i item) bool {
+			if i.Kind == stepKind && i.Step.Result != nil && i.Step.Result.ErrorType != "" {
+				counts[i.Step.Result.ErrorType]++
+			}
+			return true
+		},
+	})
+	return counts
+}
+
+// toSpecErrorTypes lists the error types of the failed steps of s, separated by spaces.
+func toSpecErrorTypes(s *spec) string {
+	return joinErrorTypes(failedErrorTypes(s))
+}
+
+// toScenarioErrorTypes lists the error types of the failed steps of scn, separated by spaces.
+func toScenarioErrorTypes(scn *scenario) string {
+	return joinErrorTypes(failedErrorTypes(&spec{Scenarios: []*scenario{scn}}))
+}
+
+func joinErrorTypes(counts map[errorType]int) string {
+	types := make([]string, 0)
+	for _, t := range errorTypes {
+		if counts[t] > 0 {
+			types = append(types, string(t))
+		}
+	}
+	return strings.Join(types, " ")
+}
+
 func toHookFailure(failure *gm.ProtoHookFailure, hookName string) *hookFailure {
 	if failure == nil {
 		return nil
@@ -148,45 +234,63 @@ func toHookFailure(failure *gm.ProtoHookFailure, hookName string) *hookFailure {
 	}
 }
 
-func toHTMLFileName(specName, basePath string) string {
-	specPath, err := filepath.Rel(basePath, specName)
+// relativeSpecPath is the slash separated path of a spec file relative to root, which is the
+// project root or the directory of the report page linking to the spec. It is the base name of
+// the file if it cannot be made relative to root.
+func relativeSpecPath(root, fileName string) string {
+	p, err := filepath.Rel(root, fileName)
 	if err != nil {
-		specPath = filepath.Join(basePath, filepath.Base(specName))
+		p = filepath.Base(fileName)
 	}
+	return filepath.ToSlash(p)
+}
+
+func toHTMLFileName(specName, basePath string) string {
+	specPath := filepath.FromSlash(relativeSpecPath(basePath, specName))
 	// specPath = strings.Replace(specPath, string(filepath.Separator), "_", -1)
 	ext := filepath.Ext(specPath)
 	return strings.TrimSuffix(specPath, ext) + dothtml
 }
 
-func getFilePathBasedOnSpecLocation(specFilePath, path string) string {
+func getFilePathBasedOnSpecLocation(root, specFilePath, path string) string {
 	if specFilePath != "" {
 		return filepath.Dir(specFilePath)
 	}
 	if path != "" {
-		return filepath.Join(projectRoot, path)
+		return filepath.Join(root, path)
 	}
-	return projectRoot
+	return root
 }
 
 func toSidebar(res *SuiteResult, specFilePath string) *sidebar {
-	basePath := getFilePathBasedOnSpecLocation(specFilePath, res.BasePath)
+	basePath := getFilePathBasedOnSpecLocation(res.ProjectRoot, specFilePath, res.BasePath)
 	specsMetaList := make([]*specsMeta, 0)
-	for _, specRes := range res.SpecResults {
+	for i, specRes := range res.SpecResults {
 		sm := &specsMeta{
 			SpecName:      specRes.SpecHeading,
 			ExecutionTime: formatTime(specRes.ExecutionTime),
 			Failed:        specRes.ExecutionStatus == fail,
 			Skipped:       specRes.ExecutionStatus == skip,
+			Duration:      specRes.ExecutionTime,
 			Tags:          specRes.Tags,
 			ReportFile:    toHTMLFileName(specRes.FileName, basePath),
+			ErrorTypes:    toSpecErrorTypes(specRes),
+			Order:         i,
 		}
 		specsMetaList = append(specsMetaList, sm)
 	}
-	sort.Sort(byStatus(specsMetaList))
+	sort.Stable(byStatus(specsMetaList))
 
+	hasVerificationFailures := false
+	for _, sm := range specsMetaList {
+		if strings.Contains(sm.ErrorTypes, string(verificationErrorType)) {
+			hasVerificationFailures = true
+		}
+	}
 	return &sidebar{
-		IsBeforeHookFailure: res.BeforeSuiteHookFailure != nil,
-		Specs:               specsMetaList,
+		IsBeforeHookFailure:     res.BeforeSuiteHookFailure != nil,
+		HasVerificationFailures: hasVerificationFailures,
+		Specs:                   specsMetaList,
 	}
 }
 
@@ -228,6 +332,9 @@ func (s bySceStatus) Less(i, j int) bool {
 
 func getSceState(s *scenario) int {
 	if s.ExecutionStatus == fail {
+		return -2
+	}
+	if s.ExecutionStatus == recoverableFail {
 		return -1
 	}
 	if s.ExecutionStatus == skip {
@@ -249,6 +356,7 @@ func toSpecHeader(res *spec) *specHeader {
 		SpecName:      res.SpecHeading,
 		ExecutionTime: formatTime(res.ExecutionTime),
 		FileName:      res.FileName,
+		SourceURL:     res.SourceURL,
 		Tags:          res.Tags,
 		Summary:       toScenarioSummary(res),
 	}
@@ -284,6 +392,7 @@ func toSpec(res *gm.ProtoSpecResult) *spec {
 		return spec
 	}
 	isTableScanned := false
+	rowTimes := make(map[int]int64)
 	for _, item := range res.GetProtoSpec().GetItems() {
 		switch item.GetItemType() {
 		case gm.ProtoItem_Comment:
@@ -298,7 +407,9 @@ func toSpec(res *gm.ProtoSpecResult) *spec {
 		case gm.ProtoItem_Scenario:
 			spec.Scenarios = append(spec.Scenarios, toScenario(item.GetScenario(), -1))
 		case gm.ProtoItem_TableDrivenScenario:
-			spec.Scenarios = append(spec.Scenarios, toScenario(item.GetTableDrivenScenario().GetScenario(), int(item.GetTableDrivenScenario().GetTableRowIndex())))
+			tds := item.GetTableDrivenScenario()
+			spec.Scenarios = append(spec.Scenarios, toScenario(tds.GetScenario(), int(tds.GetTableRowIndex())))
+			rowTimes[int(tds.GetTableRowIndex())] += tds.GetScenario().GetExecutionTime()
 		}
 	}
 	for _, preHookFailure := range res.GetProtoSpec().GetPreHookFailures() {
@@ -309,17 +420,75 @@ func toSpec(res *gm.ProtoSpecResult) *spec {
 	}
 
 	if res.GetProtoSpec().GetIsTableDriven() {
-		computeTableDrivenStatuses(spec)
+		computeTableDrivenStatuses(spec, res.GetFailedDataTableRows(), res.GetSkippedDataTableRows())
+		setRowExecutionTimes(spec.Datatable, rowTimes)
 	}
-	p, f, s := computeScenarioStatistics(spec)
+	assignAnchors(spec)
+	for i, scn := range spec.Scenarios {
+		scn.Position = i
+	}
+	p, f, s, r := computeScenarioStatistics(spec)
 	spec.PassedScenarioCount = p
 	spec.FailedScenarioCount = f
 	spec.SkippedScenarioCount = s
-	sort.Sort(bySceStatus(spec.Scenarios))
+	spec.RecoverableScenarioCount = r
+	sort.Stable(bySceStatus(spec.Scenarios))
 	return spec
 }
 
-func computeScenarioStatistics(s *spec) (passed, failed, skipped int) {
+// assignAnchors numbers scenarios and steps in the order they appear in the spec, so that
+// the anchors stay the same across runs irrespective of the order scenarios are displayed in.
+func assignAnchors(s *spec) {
+	stepCount := 0
+	for i, scn := range s.Scenarios {
+		scn.Anchor = toScenarioAnchor(scn, i)
+	}
+	model.WalkSpec(s, model.VisitorFuncs{
+		Item: func(_ *scenario, i item) bool {
+			var st *step
+			if i.Kind == stepKind {
+				st = i.Step
+			} else if i.Kind == conceptKind {
+				st = i.Concept.ConceptStep
+			}
+			if st != nil {
+				stepCount++
+				st.Anchor = fmt.Sprintf("step-%d", stepCount)
+			}
+			return true
+		},
+	})
+}
+
+var nonAnchorChars = regexp.MustCompile("[^A-Za-z0-9_-]+")
+
+// toScenarioAnchor derives the anchor of a scenario from the line it starts at, which Gauge
+// also uses in the scenario ID (<file>:<line>). The file part is dropped as it is machine
+// specific and already identified by the page. Table driven scenarios get the (1-based)
+// row appended. Scenarios without ID and span fall back to their position in the spec.
+func toScenarioAnchor(scn *scenario, position int) string {
+	id := ""
+	if i := strings.LastIndex(scn.ID, ":"); i >= 0 {
+		if _, err := strconv.Atoi(scn.ID[i+1:]); err == nil {
+			id = scn.ID[i+1:]
+		}
+	}
+	if id == "" && scn.Span != nil && scn.Span.Start > 0 {
+		id = strconv.FormatInt(scn.Span.Start, 10)
+	}
+	if id == "" && scn.ID != "" {
+		id = strings.Trim(nonAnchorChars.ReplaceAllString(scn.ID, "-"), "-")
+	}
+	if id == "" {
+		return fmt.Sprintf("scenario-%d", position+1)
+	}
+	if scn.TableRowIndex >= 0 {
+		id = fmt.Sprintf("%s-row-%d", id, scn.TableRowIndex+1)
+	}
+	return "scenario-" + id
+}
+
+func computeScenarioStatistics(s *spec) (passed, failed, skipped, recoverable int) {
 	for _, scn := range s.Scenarios {
 		switch scn.ExecutionStatus {
 		case pass:
@@ -328,9 +497,11 @@ func computeScenarioStatistics(s *spec) (passed, failed, skipped int) {
 			failed++
 		case skip:
 			skipped++
+		case recoverableFail:
+			recoverable++
 		}
 	}
-	return passed, failed, skipped
+	return passed, failed, skipped, recoverable
 }
 
 func toErrors(errors []*gm.Error) []buildError {
@@ -356,42 +527,99 @@ func hasParseErrors(errors []*gm.Error) bool {
 	return false
 }
 
-func computeTableDrivenStatuses(spec *spec) {
-	for _, r := range spec.Datatable.Rows {
-		r.Result = skip
+// computeTableDrivenStatuses sets the status of each data table row. Gauge reports the failed and
+// skipped rows of a spec, every other row with an executed scenario passed and rows without one,
+// e.g. those left out with --table-rows, are skipped. Older versions of Gauge do not report them,
+// in which case the statuses are derived from the scenarios executed for each row. Row indices that
+// are out of range of the data table are ignored.
+func computeTableDrivenStatuses(spec *spec, failedRows, skippedRows []int32) {
+	if spec.Datatable == nil {
+		return
 	}
-	for _, s := range spec.Scenarios {
-		if s.TableRowIndex >= 0 {
-			var row = spec.Datatable.Rows[s.TableRowIndex]
-			if s.ExecutionStatus == fail {
-				row.Result = fail
-			} else if row.Result != fail && s.ExecutionStatus == pass {
-				row.Result = pass
+	if len(failedRows) > 0 || len(skippedRows) > 0 {
+		for _, r := range spec.Datatable.Rows {
+			r.Result = skip
+		}
+		for _, s := range spec.Scenarios {
+			if r := tableRow(spec.Datatable, s.TableRowIndex); r != nil && isExecuted(s) {
+				r.Result = pass
+			}
+		}
+		for _, i := range skippedRows {
+			if r := tableRow(spec.Datatable, int(i)); r != nil {
+				r.Result = skip
+			}
+		}
+		for _, i := range failedRows {
+			if r := tableRow(spec.Datatable, int(i)); r != nil {
+				r.Result = fail
 			}
 		}
+	} else {
+		deriveTableDrivenStatuses(spec)
 	}
 	SetRowFailures(spec.BeforeSpecHookFailures, spec)
 	SetRowFailures(spec.AfterSpecHookFailures, spec)
 }
 
+func isExecuted(s *scenario) bool {
+	return s.ExecutionStatus == pass || s.ExecutionStatus == fail || s.ExecutionStatus == recoverableFail
+}
+
+func deriveTableDrivenStatuses(spec *spec) {
+	for _, r := range spec.Datatable.Rows {
+		r.Result = skip
+	}
+	for _, s := range spec.Scenarios {
+		row := tableRow(spec.Datatable, s.TableRowIndex)
+		if row == nil {
+			continue
+		}
+		if s.ExecutionStatus == fail || s.ExecutionStatus == recoverableFail {
+			row.Result = fail
+		} else if row.Result != fail && s.ExecutionStatus == pass {
+			row.Result = pass
+		}
+	}
+}
+
 func SetRowFailures(failures []*hookFailure, spec *spec) {
 	for _, f := range failures {
-		if f.TableRowIndex >= 0 {
-			spec.Datatable.Rows[f.TableRowIndex].Result = fail
+		if r := tableRow(spec.Datatable, int(f.TableRowIndex)); r != nil {
+			r.Result = fail
+		}
+	}
+}
+
+// setRowExecutionTimes sets the time taken by the scenarios executed for each data table row.
+// Rows for which no scenario was executed are left without one.
+func setRowExecutionTimes(t *table, rowTimes map[int]int64) {
+	for i, ms := range rowTimes {
+		if r := tableRow(t, i); r != nil {
+			r.ExecutionTime = formatTime(ms)
 		}
 	}
 }
 
+// tableRow returns the row at index i of t, or nil if there is no such row.
+func tableRow(t *table, i int) *row {
+	if t == nil || i < 0 || i >= len(t.Rows) {
+		return nil
+	}
+	return t.Rows[i]
+}
+
 func toScenarioSummary(s *spec) *summary {
-	var sum = summary{Failed: s.FailedScenarioCount, Passed: s.PassedScenarioCount, Skipped: s.SkippedScenarioCount}
-	sum.Total = sum.Failed + sum.Passed + sum.Skipped
+	var sum = summary{Failed: s.FailedScenarioCount, Passed: s.PassedScenarioCount, Skipped: s.SkippedScenarioCount, Recoverable: s.RecoverableScenarioCount}
+	sum.Total = sum.Failed + sum.Passed + sum.Skipped + sum.Recoverable
 	return &sum
 }
 
 func toScenario(scn *gm.ProtoScenario, tableRowIndex int) *scenario {
-	return &scenario{
+	s := &scenario{
 		Heading:                   scn.GetScenarioHeading(),
 		ExecutionTime:             formatTime(scn.GetExecutionTime()),
+		Duration:                  scn.GetExecutionTime(),
 		Tags:                      scn.GetTags(),
 		ExecutionStatus:           getScenarioStatus(scn),
 		Contexts:                  getItems(scn.GetContexts()),
@@ -400,7 +628,46 @@ func toScenario(scn *gm.ProtoScenario, tableRowIndex int) *scenario {
 		BeforeScenarioHookFailure: toHookFailure(scn.GetPreHookFailure(), "Before Scenario"),
 		AfterScenarioHookFailure:  toHookFailure(scn.GetPostHookFailure(), "After Scenario"),
 		TableRowIndex:             tableRowIndex,
+		ID:                        scn.GetID(),
+		Span:                      toSpan(scn.GetSpan()),
+		SkipErrors:                scn.GetSkipErrors(),
+	}
+	if s.ExecutionStatus == fail && s.BeforeScenarioHookFailure == nil && s.AfterScenarioHookFailure == nil &&
+		hasOnlyRecoverableFailures(s.Contexts, s.Items, s.Teardowns) {
+		s.ExecutionStatus = recoverableFail
+	}
+	return s
+}
+
+// hasOnlyRecoverableFailures tells whether the given steps failed, but only with recoverable errors.
+func hasOnlyRecoverableFailures(items ...[]item) bool {
+	recoverable, fatal := 0, 0
+	var count func(items []item)
+	count = func(items []item) {
+		for _, i := range items {
+			if i.Kind == conceptKind {
+				count(i.Concept.Items)
+			} else if i.Kind == stepKind && i.Step.Result != nil {
+				switch i.Step.Result.Status {
+				case recoverableFail:
+					recoverable++
+				case fail:
+					fatal++
+				}
+			}
+		}
 	}
+	for _, i := range items {
+		count(i)
+	}
+	return recoverable > 0 && fatal == 0
+}
+
+func toSpan(s *gm.Span) *model.Span {
+	if s == nil {
+		return nil
+	}
+	return &model.Span{Start: s.GetStart(), End: s.GetEnd()}
 }
 
 func toComment(protoComment *gm.ProtoComment) *comment {
@@ -416,10 +683,14 @@ func toStep(protoStep *gm.ProtoStep) *step {
 		ErrorMessage:  res.GetErrorMessage(),
 		ExecutionTime: formatTime(res.GetExecutionTime()),
 		Messages:      res.GetMessage(),
+		Duration:      res.GetExecutionTime(),
 	}
 	if protoStep.GetStepExecutionResult().GetSkipped() {
 		result.SkippedReason = protoStep.GetStepExecutionResult().GetSkippedReason()
 	}
+	if result.Status == fail || result.Status == recoverableFail {
+		result.ErrorType = toErrorType(res.GetErrorType())
+	}
 	return &step{
 		Fragments:             toFragments(protoStep.GetFragments()),
 		Result:                result,
@@ -428,12 +699,24 @@ func toStep(protoStep *gm.ProtoStep) *step {
 	}
 }
 
+func toErrorType(t gm.ProtoExecutionResult_ErrorType) errorType {
+	if t == gm.ProtoExecutionResult_VERIFICATION {
+		return verificationErrorType
+	}
+	return assertionErrorType
+}
+
 func toConcept(protoConcept *gm.ProtoConcept) *concept {
 	protoConcept.ConceptStep.StepExecutionResult = protoConcept.GetConceptExecutionResult()
-	return &concept{
+	c := &concept{
 		ConceptStep: toStep(protoConcept.GetConceptStep()),
 		Items:       getItems(protoConcept.GetSteps()),
 	}
+	if c.ConceptStep.Result.Status == fail && c.ConceptStep.BeforeStepHookFailure == nil && c.ConceptStep.AfterStepHookFailure == nil &&
+		hasOnlyRecoverableFailures(c.Items) {
+		c.ConceptStep.Result.Status = recoverableFail
+	}
+	return c
 }
 
 func toFileName(name string) string {
@@ -509,6 +792,9 @@ func getStepStatus(res *gm.ProtoStepExecutionResult) status {
 		return notExecuted
 	}
 	if res.GetExecutionResult().GetFailed() {
+		if res.GetExecutionResult().GetRecoverableError() {
+			return recoverableFail
+		}
 		return fail
 	}
 	return pass
@@ -19,11 +19,13 @@ package generator
 
 import (
 	"encoding/base64"
+	"fmt"
 	"path/filepath"
 	"reflect"
 	"testing"
 
 	gm "github.com/getgauge/html-report/gauge_messages"
+	"github.com/getgauge/html-report/model"
 	"github.com/kylelemons/godebug/pretty"
 )
 
@@ -505,6 +507,10 @@ func TestToSidebar(t *testing.T) {
 			newSpecsMeta("specRes1", "00:03:31", false, false, []string{"tag1", "tag2"}, "foobar.html"),
 		},
 	}
+	for i, sm := range want.Specs {
+		sm.Duration = 211316
+		sm.Order = []int{1, 2, 0}[i]
+	}
 
 	got := toSidebar(suiteRes2, "")
 	if !reflect.DeepEqual(got, want) {
@@ -623,7 +629,7 @@ func TestToSpecForTableDrivenSpec(t *testing.T) {
 	want := &spec{
 		Datatable: &table{
 			Headers: []string{"Word", "Count"},
-			Rows:    []*row{{Cells: []string{"Gauge", "3"}, Result: fail}, {Cells: []string{"Mingle", "2"}, Result: pass}},
+			Rows:    []*row{{Cells: []string{"Gauge", "3"}, Result: fail, ExecutionTime: "00:00:00"}, {Cells: []string{"Mingle", "2"}, Result: pass, ExecutionTime: "00:00:00"}},
 		},
 		SpecHeading:     "specRes1",
 		FileName:        "/tmp/gauge/specs/foobar.spec",
@@ -639,7 +645,8 @@ func TestToSpecForTableDrivenSpec(t *testing.T) {
 						Kind: stepKind,
 						Step: &step{
 							Fragments: []*fragment{{FragmentKind: textFragmentKind, Text: "Step1"}},
-							Result:    &result{Status: fail, ExecutionTime: "00:03:31"},
+							Result:    &result{Status: fail, ExecutionTime: "00:03:31", ErrorType: assertionErrorType, Duration: 211316},
+							Anchor:    "step-1",
 						},
 					},
 				},
@@ -647,6 +654,7 @@ func TestToSpecForTableDrivenSpec(t *testing.T) {
 				Teardowns:                 make([]item, 0),
 				ExecutionStatus:           fail,
 				TableRowIndex:             0,
+				Anchor:                    "scenario-1",
 				BeforeScenarioHookFailure: nil,
 				AfterScenarioHookFailure:  nil,
 			},
@@ -658,7 +666,8 @@ func TestToSpecForTableDrivenSpec(t *testing.T) {
 						Kind: stepKind,
 						Step: &step{
 							Fragments: []*fragment{{FragmentKind: textFragmentKind, Text: "Step1"}},
-							Result:    &result{Status: pass, ExecutionTime: "00:03:31"},
+							Result:    &result{Status: pass, ExecutionTime: "00:03:31", Duration: 211316},
+							Anchor:    "step-2",
 						},
 					},
 				},
@@ -666,6 +675,8 @@ func TestToSpecForTableDrivenSpec(t *testing.T) {
 				Teardowns:                 make([]item, 0),
 				ExecutionStatus:           pass,
 				TableRowIndex:             1,
+				Anchor:                    "scenario-2",
+				Position:                  1,
 				BeforeScenarioHookFailure: nil,
 				AfterScenarioHookFailure:  nil,
 			},
@@ -961,6 +972,7 @@ func TestToScenario(t *testing.T) {
 	want := &scenario{
 		Heading:         "Vowel counts in single word",
 		ExecutionTime:   "00:01:53",
+		Duration:        113163,
 		ExecutionStatus: pass,
 		Tags:            []string{"foo", "bar"},
 		Contexts: []item{
@@ -968,14 +980,14 @@ func TestToScenario(t *testing.T) {
 				Kind: stepKind,
 				Step: &step{
 					Fragments: []*fragment{{FragmentKind: textFragmentKind, Text: "Context Step1"}},
-					Result:    &result{Status: pass, ExecutionTime: "00:03:31"},
+					Result:    &result{Status: pass, ExecutionTime: "00:03:31", Duration: 211316},
 				},
 			},
 			item{
 				Kind: stepKind,
 				Step: &step{
 					Fragments: []*fragment{{FragmentKind: textFragmentKind, Text: "Context Step2"}},
-					Result:    &result{Status: fail, ExecutionTime: "00:03:31"},
+					Result:    &result{Status: fail, ExecutionTime: "00:03:31", ErrorType: assertionErrorType, Duration: 211316},
 				},
 			},
 		},
@@ -988,7 +1000,7 @@ func TestToScenario(t *testing.T) {
 				Kind: stepKind,
 				Step: &step{
 					Fragments: []*fragment{{FragmentKind: textFragmentKind, Text: "Step1"}},
-					Result:    &result{Status: fail, ExecutionTime: "00:03:31"},
+					Result:    &result{Status: fail, ExecutionTime: "00:03:31", ErrorType: assertionErrorType, Duration: 211316},
 				},
 			},
 			item{
@@ -1003,7 +1015,7 @@ func TestToScenario(t *testing.T) {
 				Kind: stepKind,
 				Step: &step{
 					Fragments: []*fragment{{FragmentKind: textFragmentKind, Text: "Step2"}},
-					Result:    &result{Status: pass, ExecutionTime: "00:03:31"},
+					Result:    &result{Status: pass, ExecutionTime: "00:03:31", Duration: 211316},
 				},
 			},
 			item{
@@ -1016,14 +1028,14 @@ func TestToScenario(t *testing.T) {
 				Kind: stepKind,
 				Step: &step{
 					Fragments: []*fragment{{FragmentKind: textFragmentKind, Text: "Teardown Step1"}},
-					Result:    &result{Status: pass, ExecutionTime: "00:03:31"},
+					Result:    &result{Status: pass, ExecutionTime: "00:03:31", Duration: 211316},
 				},
 			},
 			item{
 				Kind: stepKind,
 				Step: &step{
 					Fragments: []*fragment{{FragmentKind: textFragmentKind, Text: "Teardown Step2"}},
-					Result:    &result{Status: fail, ExecutionTime: "00:03:31"},
+					Result:    &result{Status: fail, ExecutionTime: "00:03:31", ErrorType: assertionErrorType, Duration: 211316},
 				},
 			},
 		},
@@ -1041,6 +1053,7 @@ func TestToScenarioWithHookFailures(t *testing.T) {
 	want := &scenario{
 		Heading:         "Vowel counts in single word",
 		ExecutionTime:   "00:01:53",
+		Duration:        113163,
 		ExecutionStatus: fail,
 		Contexts:        []item{},
 		Items: []item{
@@ -1048,7 +1061,7 @@ func TestToScenarioWithHookFailures(t *testing.T) {
 				Kind: stepKind,
 				Step: &step{
 					Fragments: []*fragment{{FragmentKind: textFragmentKind, Text: "Step1"}},
-					Result:    &result{Status: fail, ExecutionTime: "00:03:31"},
+					Result:    &result{Status: fail, ExecutionTime: "00:03:31", ErrorType: assertionErrorType, Duration: 211316},
 				},
 			},
 		},
@@ -1062,6 +1075,234 @@ func TestToScenarioWithHookFailures(t *testing.T) {
 	checkEqual(t, "", want, got)
 }
 
+func newRecoverableStepItem(text string) *gm.ProtoItem {
+	i := newStepItem(true, false, []*gm.Fragment{newTextFragment(text)})
+	i.Step.StepExecutionResult.ExecutionResult.RecoverableError = true
+	return i
+}
+
+func TestToStepWithRecoverableError(t *testing.T) {
+	got := toStep(newRecoverableStepItem("Step1").GetStep())
+
+	if got.Result.Status != recoverableFail {
+		t.Errorf("want: %q, got: %q", recoverableFail, got.Result.Status)
+	}
+}
+
+func TestToScenarioWithOnlyRecoverableFailures(t *testing.T) {
+	scn := &gm.ProtoScenario{
+		ScenarioHeading: "foo",
+		ExecutionStatus: gm.ExecutionStatus_FAILED,
+		ScenarioItems: []*gm.ProtoItem{
+			newRecoverableStepItem("Step1"),
+			newStepItem(false, false, []*gm.Fragment{newTextFragment("Step2")}),
+			newConceptItem("Concept", []*gm.ProtoItem{newRecoverableStepItem("Step3")},
+				&gm.ProtoStepExecutionResult{ExecutionResult: &gm.ProtoExecutionResult{Failed: true}}),
+		},
+	}
+
+	got := toScenario(scn, -1)
+
+	if got.ExecutionStatus != recoverableFail {
+		t.Errorf("want: %q, got: %q", recoverableFail, got.ExecutionStatus)
+	}
+	if s := got.Items[2].Concept.ConceptStep.Result.Status; s != recoverableFail {
+		t.Errorf("Expected concept to be a recoverable failure, got %q", s)
+	}
+}
+
+func TestToScenarioWithFatalAndRecoverableFailures(t *testing.T) {
+	scn := &gm.ProtoScenario{
+		ScenarioHeading: "foo",
+		ExecutionStatus: gm.ExecutionStatus_FAILED,
+		ScenarioItems: []*gm.ProtoItem{
+			newRecoverableStepItem("Step1"),
+			newStepItem(true, false, []*gm.Fragment{newTextFragment("Step2")}),
+		},
+	}
+
+	got := toScenario(scn, -1)
+
+	if got.ExecutionStatus != fail {
+		t.Errorf("want: %q, got: %q", fail, got.ExecutionStatus)
+	}
+}
+
+func TestToSpecCountsRecoverableScenariosSeparately(t *testing.T) {
+	got := toSpec(&gm.ProtoSpecResult{
+		ProtoSpec: &gm.ProtoSpec{
+			Items: []*gm.ProtoItem{
+				newScenarioItem(&gm.ProtoScenario{ScenarioHeading: "recoverable", ExecutionStatus: gm.ExecutionStatus_FAILED,
+					ScenarioItems: []*gm.ProtoItem{newRecoverableStepItem("Step1")}}),
+				newScenarioItem(&gm.ProtoScenario{ScenarioHeading: "failed", ExecutionStatus: gm.ExecutionStatus_FAILED,
+					ScenarioItems: []*gm.ProtoItem{newStepItem(true, false, []*gm.Fragment{newTextFragment("Step1")})}}),
+				newScenarioItem(&gm.ProtoScenario{ScenarioHeading: "passed", ExecutionStatus: gm.ExecutionStatus_PASSED}),
+			},
+		},
+	})
+
+	if got.FailedScenarioCount != 1 || got.RecoverableScenarioCount != 1 || got.PassedScenarioCount != 1 {
+		t.Errorf("Unexpected counts: failed %d, recoverable %d, passed %d", got.FailedScenarioCount, got.RecoverableScenarioCount, got.PassedScenarioCount)
+	}
+	checkEqual(t, "", &summary{Total: 3, Failed: 1, Passed: 1, Recoverable: 1}, toScenarioSummary(got))
+	headings := []string{got.Scenarios[0].Heading, got.Scenarios[1].Heading, got.Scenarios[2].Heading}
+	checkEqual(t, "", []string{"failed", "recoverable", "passed"}, headings)
+}
+
+func TestToScenarioMapsIDAndSpan(t *testing.T) {
+	scn := &gm.ProtoScenario{
+		ScenarioHeading: "foo",
+		ExecutionStatus: gm.ExecutionStatus_PASSED,
+		ID:              "/specs/example.spec:12",
+		Span:            &gm.Span{Start: 12, End: 20},
+	}
+
+	got := toScenario(scn, -1)
+
+	if got.ID != "/specs/example.spec:12" {
+		t.Errorf("want: %q, got: %q", "/specs/example.spec:12", got.ID)
+	}
+	checkEqual(t, "", &model.Span{Start: 12, End: 20}, got.Span)
+}
+
+func TestToScenarioMapsSkipErrors(t *testing.T) {
+	scn := &gm.ProtoScenario{
+		ScenarioHeading: "foo",
+		ExecutionStatus: gm.ExecutionStatus_SKIPPED,
+		Skipped:         true,
+		SkipErrors:      []string{"Step implementation not found"},
+	}
+
+	got := toScenario(scn, -1)
+
+	checkEqual(t, "", []string{"Step implementation not found"}, got.SkipErrors)
+}
+
+func TestToScenarioAnchor(t *testing.T) {
+	tests := []struct {
+		name string
+		scn  *scenario
+		want string
+	}{
+		{"line from id", &scenario{ID: "/specs/example.spec:12", TableRowIndex: -1}, "scenario-12"},
+		{"span start", &scenario{Span: &model.Span{Start: 7, End: 9}, TableRowIndex: -1}, "scenario-7"},
+		{"id without line", &scenario{ID: "my scenario/1", TableRowIndex: -1}, "scenario-my-scenario-1"},
+		{"table row", &scenario{ID: "/specs/example.spec:12", TableRowIndex: 2}, "scenario-12-row-3"},
+		{"position", &scenario{TableRowIndex: 2}, "scenario-4"},
+	}
+	for _, test := range tests {
+		got := toScenarioAnchor(test.scn, 3)
+		if got != test.want {
+			t.Errorf("%s - want: %q, got: %q", test.name, test.want, got)
+		}
+	}
+}
+
+func TestToStepWithVerificationError(t *testing.T) {
+	i := newStepItem(true, false, []*gm.Fragment{newTextFragment("Step1")})
+	i.Step.StepExecutionResult.ExecutionResult.ErrorType = gm.ProtoExecutionResult_VERIFICATION
+
+	got := toStep(i.GetStep())
+
+	if got.Result.ErrorType != verificationErrorType {
+		t.Errorf("want: %q, got: %q", verificationErrorType, got.Result.ErrorType)
+	}
+}
+
+func TestToStepWithoutFailureHasNoErrorType(t *testing.T) {
+	got := toStep(newStepItem(false, false, []*gm.Fragment{newTextFragment("Step1")}).GetStep())
+
+	if got.Result.ErrorType != "" {
+		t.Errorf("Expected no error type, got %q", got.Result.ErrorType)
+	}
+}
+
+func TestCountErrorTypes(t *testing.T) {
+	failed := func(t errorType) item {
+		return item{Kind: stepKind, Step: &step{Result: &result{Status: fail, ErrorType: t}}}
+	}
+	specs := []*spec{
+		{Scenarios: []*scenario{{Items: []item{failed(verificationErrorType), failed(assertionErrorType), failed(verificationErrorType)}}}},
+		{Scenarios: []*scenario{{Items: []item{
+			{Kind: conceptKind, Concept: &concept{ConceptStep: &step{Result: &result{Status: fail, ErrorType: assertionErrorType}}, Items: []item{failed(assertionErrorType)}}},
+		}}}},
+		{Scenarios: []*scenario{{Items: []item{{Kind: stepKind, Step: &step{Result: &result{Status: pass}}}}}}},
+	}
+
+	got := countErrorTypes(specs)
+
+	checkEqual(t, "", []*errorTypeCount{{Type: assertionErrorType, Count: 2}, {Type: verificationErrorType, Count: 2}}, got)
+	if s := toSpecErrorTypes(specs[0]); s != "assertion verification" {
+		t.Errorf("want: %q, got: %q", "assertion verification", s)
+	}
+	if s := toSpecErrorTypes(specs[2]); s != "" {
+		t.Errorf("Expected no error types, got %q", s)
+	}
+}
+
+func TestToScenarioErrorTypes(t *testing.T) {
+	scn := &scenario{Items: []item{
+		{Kind: stepKind, Step: &step{Result: &result{Status: fail, ErrorType: verificationErrorType}}},
+		{Kind: stepKind, Step: &step{Result: &result{Status: pass}}},
+		{Kind: stepKind, Step: &step{Result: &result{Status: fail, ErrorType: assertionErrorType}}},
+	}}
+
+	got := toScenarioErrorTypes(scn)
+
+	if got != "assertion verification" {
+		t.Errorf("want: %q, got: %q", "assertion verification", got)
+	}
+}
+
+func TestToSpecKeepsSourceOrderWithinStatus(t *testing.T) {
+	scenarioItem := func(heading string, status gm.ExecutionStatus) *gm.ProtoItem {
+		return &gm.ProtoItem{ItemType: gm.ProtoItem_Scenario, Scenario: &gm.ProtoScenario{ScenarioHeading: heading, ExecutionStatus: status}}
+	}
+	res := &gm.ProtoSpecResult{ProtoSpec: &gm.ProtoSpec{Items: []*gm.ProtoItem{
+		scenarioItem("a", gm.ExecutionStatus_PASSED),
+		scenarioItem("b", gm.ExecutionStatus_FAILED),
+		scenarioItem("c", gm.ExecutionStatus_PASSED),
+		scenarioItem("d", gm.ExecutionStatus_FAILED),
+		scenarioItem("e", gm.ExecutionStatus_PASSED),
+	}}}
+
+	got := make([]string, 0)
+	for _, scn := range toSpec(res).Scenarios {
+		got = append(got, fmt.Sprintf("%s%d", scn.Heading, scn.Position))
+	}
+
+	checkEqual(t, "", []string{"b1", "d3", "a0", "c2", "e4"}, got)
+}
+
+func TestToSidebarNumbersSpecsInExecutionOrder(t *testing.T) {
+	res := &SuiteResult{SpecResults: []*spec{
+		{FileName: "z.spec", ExecutionStatus: pass},
+		{FileName: "a.spec", ExecutionStatus: fail},
+		{FileName: "m.spec", ExecutionStatus: pass},
+	}}
+
+	got := make([]string, 0)
+	for _, sm := range toSidebar(res, "").Specs {
+		got = append(got, fmt.Sprintf("%s%d", sm.ReportFile, sm.Order))
+	}
+
+	checkEqual(t, "", []string{"a.html1", "z.html0", "m.html2"}, got)
+}
+
+func TestToSidebarFlagsVerificationFailures(t *testing.T) {
+	res := &SuiteResult{SpecResults: []*spec{
+		{FileName: "foo.spec", Scenarios: []*scenario{{Items: []item{
+			{Kind: stepKind, Step: &step{Result: &result{Status: fail, ErrorType: verificationErrorType}}},
+		}}}},
+	}}
+
+	got := toSidebar(res, "")
+
+	if !got.HasVerificationFailures || got.Specs[0].ErrorTypes != "verification" {
+		t.Errorf("Expected verification failures to be flagged, got %v, %q", got.HasVerificationFailures, got.Specs[0].ErrorTypes)
+	}
+}
+
 func TestToConcept(t *testing.T) {
 	want := &concept{
 		ConceptStep: &step{
@@ -1076,7 +1317,7 @@ func TestToConcept(t *testing.T) {
 					},
 				},
 			},
-			Result: &result{Status: pass, ExecutionTime: "00:03:31"},
+			Result: &result{Status: pass, ExecutionTime: "00:03:31", Duration: 211316},
 		},
 		Items: []item{
 			item{
@@ -1087,14 +1328,14 @@ func TestToConcept(t *testing.T) {
 							{FragmentKind: textFragmentKind, Text: "Tell "},
 							{FragmentKind: dynamicFragmentKind, Text: "hello"},
 						},
-						Result: &result{Status: pass, ExecutionTime: "00:03:31"},
+						Result: &result{Status: pass, ExecutionTime: "00:03:31", Duration: 211316},
 					},
 					Items: []item{
 						item{
 							Kind: stepKind,
 							Step: &step{
 								Fragments: []*fragment{{FragmentKind: textFragmentKind, Text: "Say Hi"}},
-								Result:    &result{Status: pass, ExecutionTime: "00:03:31"},
+								Result:    &result{Status: pass, ExecutionTime: "00:03:31", Duration: 211316},
 							},
 						},
 					},
@@ -1115,7 +1356,7 @@ func TestToConcept(t *testing.T) {
 							},
 						},
 					},
-					Result: &result{Status: pass, ExecutionTime: "00:03:31"},
+					Result: &result{Status: pass, ExecutionTime: "00:03:31", Duration: 211316},
 				},
 			},
 		},
@@ -1141,7 +1382,7 @@ func TestToStep(t *testing.T) {
 				},
 			},
 		},
-		Result: &result{Status: skip, ExecutionTime: "00:03:31", SkippedReason: "Step impl not found"},
+		Result: &result{Status: skip, ExecutionTime: "00:03:31", SkippedReason: "Step impl not found", Duration: 211316},
 	}
 
 	got := toStep(protoStep)
@@ -1184,6 +1425,7 @@ Mingle,2`,
 		Result: &result{
 			Status:        pass,
 			ExecutionTime: "00:03:31",
+			Duration:      211316,
 		},
 	}
 
@@ -1202,6 +1444,8 @@ func TestToStepWithAfterHookFailure(t *testing.T) {
 		Result: &result{
 			Status:        fail,
 			ExecutionTime: "00:03:31",
+			ErrorType:     assertionErrorType,
+			Duration:      211316,
 		},
 		AfterStepHookFailure: newHookFailure("After Step", "err", encodedScreenShot, "Stacktrace"),
 	}
@@ -1356,7 +1600,7 @@ var tableDrivenStatusComputeTests = []*tableDrivenStatusComputeTest{
 func TestTableDrivenStatusCompute(t *testing.T) {
 	for _, test := range tableDrivenStatusComputeTests {
 		want := test.status
-		computeTableDrivenStatuses(test.spec)
+		computeTableDrivenStatuses(test.spec, nil, nil)
 		got := test.spec.Datatable.Rows[0].Result
 		if want != got {
 			t.Errorf("test: %s want:\n%q\ngot:\n%q\n", test.name, want, got)
@@ -1364,6 +1608,88 @@ func TestTableDrivenStatusCompute(t *testing.T) {
 	}
 }
 
+func TestTableDrivenStatusComputeUsesReportedRows(t *testing.T) {
+	s := &spec{Datatable: &table{Headers: []string{"foo"}, Rows: []*row{{Cells: []string{"foo1"}}, {Cells: []string{"foo2"}}, {Cells: []string{"foo3"}}}},
+		Scenarios: []*scenario{
+			{ExecutionStatus: pass, TableRowIndex: 0},
+			{ExecutionStatus: pass, TableRowIndex: 1},
+			{ExecutionStatus: pass, TableRowIndex: 2},
+		}}
+
+	computeTableDrivenStatuses(s, []int32{1}, []int32{2})
+
+	got := []status{s.Datatable.Rows[0].Result, s.Datatable.Rows[1].Result, s.Datatable.Rows[2].Result}
+	checkEqual(t, "", []status{pass, fail, skip}, got)
+}
+
+func TestTableDrivenStatusComputeSkipsReportedRowsWithoutScenario(t *testing.T) {
+	s := &spec{Datatable: &table{Headers: []string{"foo"}, Rows: []*row{{Cells: []string{"foo1"}}, {Cells: []string{"foo2"}}, {Cells: []string{"foo3"}}}},
+		Scenarios: []*scenario{
+			{ExecutionStatus: pass, TableRowIndex: 0},
+			{ExecutionStatus: fail, TableRowIndex: 1},
+		}}
+
+	computeTableDrivenStatuses(s, []int32{1}, nil)
+
+	got := []status{s.Datatable.Rows[0].Result, s.Datatable.Rows[1].Result, s.Datatable.Rows[2].Result}
+	checkEqual(t, "", []status{pass, fail, skip}, got)
+}
+
+func TestTableDrivenStatusComputeIgnoresRowsOutOfRange(t *testing.T) {
+	s := &spec{BeforeSpecHookFailures: []*hookFailure{{HookName: "Some failure", TableRowIndex: 3}},
+		Datatable: &table{Headers: []string{"foo"}, Rows: []*row{{Cells: []string{"foo1"}}}},
+		Scenarios: []*scenario{
+			{ExecutionStatus: pass, TableRowIndex: 0},
+			{ExecutionStatus: fail, TableRowIndex: 5},
+		}}
+
+	computeTableDrivenStatuses(s, nil, nil)
+	if s.Datatable.Rows[0].Result != pass {
+		t.Errorf("want: %q, got: %q", pass, s.Datatable.Rows[0].Result)
+	}
+
+	computeTableDrivenStatuses(s, []int32{-1, 7}, []int32{1})
+	if s.Datatable.Rows[0].Result != pass {
+		t.Errorf("want: %q, got: %q", pass, s.Datatable.Rows[0].Result)
+	}
+}
+
+func TestToSpecMapsRowExecutionTimes(t *testing.T) {
+	tableDrivenScenario := func(row int32, ms int64) *gm.ProtoItem {
+		return &gm.ProtoItem{
+			ItemType: gm.ProtoItem_TableDrivenScenario,
+			TableDrivenScenario: &gm.ProtoTableDrivenScenario{
+				Scenario:      &gm.ProtoScenario{ScenarioHeading: "foo", ExecutionStatus: gm.ExecutionStatus_PASSED, ExecutionTime: ms},
+				TableRowIndex: row,
+			},
+		}
+	}
+	res := &gm.ProtoSpecResult{
+		ProtoSpec: &gm.ProtoSpec{
+			IsTableDriven: true,
+			Items: []*gm.ProtoItem{
+				{ItemType: gm.ProtoItem_Table, Table: &gm.ProtoTable{
+					Headers: &gm.ProtoTableRow{Cells: []string{"foo"}},
+					Rows:    []*gm.ProtoTableRow{{Cells: []string{"foo1"}}, {Cells: []string{"foo2"}}, {Cells: []string{"foo3"}}},
+				}},
+				tableDrivenScenario(0, 61000),
+				tableDrivenScenario(0, 1000),
+				tableDrivenScenario(1, 3000),
+			},
+		},
+		SkippedDataTableRows: []int32{2},
+	}
+
+	got := toSpec(res).Datatable
+
+	want := &table{Headers: []string{"foo"}, Rows: []*row{
+		{Cells: []string{"foo1"}, Result: pass, ExecutionTime: "00:01:02"},
+		{Cells: []string{"foo2"}, Result: pass, ExecutionTime: "00:00:03"},
+		{Cells: []string{"foo3"}, Result: skip},
+	}}
+	checkEqual(t, "", want, got)
+}
+
 func TestMapProjectNametoSuiteResult(t *testing.T) {
 	psr := &gm.ProtoSuiteResult{ProjectName: "foo"}
 	res := ToSuiteResult("", psr)
@@ -18,11 +18,18 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"strings"
@@ -30,16 +37,20 @@ import (
 	"runtime"
 
 	"github.com/getgauge/common"
+	"github.com/getgauge/html-report/buildinfo"
 	"github.com/getgauge/html-report/env"
 	"github.com/getgauge/html-report/gauge_messages"
 	"github.com/getgauge/html-report/generator"
 	"github.com/getgauge/html-report/listener"
+	"github.com/getgauge/html-report/model"
+	"github.com/getgauge/html-report/stream"
 	"github.com/getgauge/html-report/theme"
 )
 
 const (
 	resultJsFile    = "result.js"
 	htmlReport      = "html-report"
+	htmlFormat      = "html"
 	setupAction     = "setup"
 	executionAction = "execution"
 	gaugeHost       = "localhost"
@@ -48,6 +59,8 @@ const (
 	timeFormat      = "2006-01-02 15.04.05"
 	defaultTheme    = "default"
 	resultFile      = "last_run_result.json"
+	dotGauge        = ".gauge"
+	lastRunResult   = "last_run_result"
 )
 
 type nameGenerator interface {
@@ -63,27 +76,131 @@ func (T timeStampedNameGenerator) randomName() string {
 
 var pluginsDir string
 
-func createExecutionReport() {
+// errReportFailed tells that the report of an execution could not be generated.
+var errReportFailed = errors.New("failed to generate the report of the execution")
+
+// createExecutionReport generates the report of the execution Gauge sends, once the execution
+// ends. Reports that are being generated when Gauge stops the plugin are completed first.
+func createExecutionReport() error {
 	pluginsDir, _ = os.Getwd()
 	os.Chdir(env.GetProjectRoot())
 	listener, err := listener.NewGaugeListener(gaugeHost, os.Getenv(gaugePortEnv))
 	if err != nil {
-		fmt.Println("Could not create the gauge listener")
-		os.Exit(1)
+		return fmt.Errorf("Could not create the gauge listener: %s", err.Error())
+	}
+	var failed int32
+	listener.OnSuiteResult(func(suiteResult *gauge_messages.SuiteExecutionResult) {
+		if err := createReport(suiteResult); err != nil {
+			log.Printf("%s\n", err.Error())
+			atomic.StoreInt32(&failed, 1)
+		}
+	})
+	if format := env.GetStreamFormat(); format != "" {
+		w, closeStream, err := openStream(env.GetStreamFile())
+		if err != nil {
+			log.Printf("[Warning] Failed to open the stream file: %s\n", err.Error())
+		} else {
+			defer closeStream()
+			if err := stream.Attach(listener, w, format); err != nil {
+				log.Printf("[Warning] Not streaming the execution: %s\n", err.Error())
+			}
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		<-stop
+		cancel()
+	}()
+	if err := listener.Start(ctx); err != nil && err != context.Canceled {
+		log.Printf("[Warning] Stopped listening to Gauge: %s\n", err.Error())
+	}
+	if atomic.LoadInt32(&failed) != 0 {
+		return errReportFailed
+	}
+	return nil
+}
+
+// openStream opens the file the progress of an execution is streamed to, or stdout if it is not set.
+func openStream(file string) (io.Writer, func(), error) {
+	if file == "" {
+		return os.Stdout, func() {}, nil
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return nil, nil, err
 	}
-	listener.OnSuiteResult(createReport)
-	listener.Start()
+	return f, func() { f.Close() }, nil
 }
 
-func createReport(suiteResult *gauge_messages.SuiteExecutionResult) {
+func createReport(suiteResult *gauge_messages.SuiteExecutionResult) error {
 	projectRoot, err := common.GetProjectRoot()
 	if err != nil {
-		log.Fatalf("%s", err.Error())
+		return err
 	}
 	reportsDir := getReportsDirectory(getNameGen())
-	res := generator.ToSuiteResult(projectRoot, suiteResult.GetSuiteResult())
-	go createReportExecutableFile(reportsDir, pluginsDir)
-	generator.GenerateReport(res, reportsDir, theme.GetThemePath(pluginsDir))
+	res := generator.ToSuiteResultWithBuild(projectRoot, suiteResult.GetSuiteResult(), collectBuildInfo(projectRoot))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		createReportExecutableFile(reportsDir, pluginsDir)
+	}()
+	formats := env.GetReportFormats()
+	err = generator.GenerateReport(res, reportsDir, theme.GetThemePath(pluginsDir), formats)
+	wg.Wait()
+	if !hasFormat(formats, htmlFormat) {
+		// there are no html pages to link to
+		reportsDir = ""
+	}
+	printSummary(res, reportsDir)
+	return err
+}
+
+// collectBuildInfo returns the build the suite was executed for and keeps it with the result Gauge
+// saves for the execution, so that reports regenerated from the saved result show the same build.
+func collectBuildInfo(projectRoot string) *model.BuildInfo {
+	var build *model.BuildInfo
+	if env.ShouldCollectBuildInfo() {
+		build = buildinfo.Collect(projectRoot)
+	}
+	if err := buildinfo.Save(filepath.Join(projectRoot, dotGauge, lastRunResult), build); err != nil {
+		log.Printf("[Warning] Failed to save the build information: %s\n", err.Error())
+	}
+	return build
+}
+
+func hasFormat(formats []string, format string) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+func printSummary(res *generator.SuiteResult, reportsDir string) {
+	style := generator.PlainSummary
+	switch env.GetConsoleSummary() {
+	case "off":
+		return
+	case "ansi":
+		style = generator.ANSISummary
+	case "auto":
+		if isTerminal(os.Stdout) {
+			style = generator.ANSISummary
+		}
+	}
+	fmt.Println()
+	if err := generator.WriteSummary(os.Stdout, res, reportsDir, style); err != nil {
+		log.Printf("[Warning] Failed to print the summary: %s\n", err.Error())
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
 }
 
 func getNameGen() nameGenerator {
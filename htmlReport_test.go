@@ -22,11 +22,14 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime"
 	"testing"
 	"time"
 
+	"github.com/getgauge/common"
 	"github.com/getgauge/html-report/env"
 	helper "github.com/getgauge/html-report/test_helper"
+	fakegauge "github.com/getgauge/html-report/test_helper/fake_gauge"
 )
 
 var now = time.Now()
@@ -91,3 +94,62 @@ func TestCreatingReportShouldOverwriteReportsBasedOnEnv(t *testing.T) {
 		t.Errorf("Expected nameGen to be type timeStampedNameGenerator, got %s", reflect.TypeOf(nameGen))
 	}
 }
+
+// setEnv sets the given variables and returns a func to restore their previous values.
+func setEnv(vars map[string]string) func() {
+	saved := make(map[string]*string)
+	for n, v := range vars {
+		if old, ok := os.LookupEnv(n); ok {
+			saved[n] = &old
+		} else {
+			saved[n] = nil
+		}
+		os.Setenv(n, v)
+	}
+	return func() {
+		for n, v := range saved {
+			if v == nil {
+				os.Unsetenv(n)
+			} else {
+				os.Setenv(n, *v)
+			}
+		}
+	}
+}
+
+func TestCreateExecutionReport(t *testing.T) {
+	projectRoot := filepath.Join(os.TempDir(), randomName())
+	env.CreateDirectory(projectRoot)
+	defer os.RemoveAll(projectRoot)
+	gauge, err := fakegauge.Start(fakegauge.SuiteResultMessage(fakegauge.SuiteResult(projectRoot)), fakegauge.KillProcessMessage())
+	if err != nil {
+		t.Fatalf("Unable to start Gauge: %s", err.Error())
+	}
+	defer gauge.Close()
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	defer setEnv(map[string]string{
+		common.GaugeProjectRootEnv:      projectRoot,
+		env.GaugeReportsDirEnvName:      filepath.Join(projectRoot, "reports"),
+		env.OverwriteReportsEnvProperty: "true",
+		gaugePortEnv:                    gauge.Port(),
+	})()
+
+	if err := createExecutionReport(); err != nil {
+		t.Errorf("Expected error to be nil. Got: %s", err.Error())
+	}
+
+	if err := gauge.Wait(5 * time.Second); err != nil {
+		t.Fatalf("Expected the plugin to disconnect from Gauge. Got: %s", err.Error())
+	}
+	reportDir := filepath.Join(projectRoot, "reports", htmlReport)
+	_, bName := env.GetCurrentExecutableDir()
+	for _, f := range []string{"index.html", filepath.Join("specs", "passing_specification.html"), filepath.Join("specs", "failing_specification.html"), filepath.Join("js", "search_index.js"), filepath.Join("css", "style.css")} {
+		if !helper.FileExists(filepath.Join(reportDir, f)) {
+			t.Errorf("Expected %s to be generated in %s", f, reportDir)
+		}
+	}
+	if _, err := os.Lstat(filepath.Join(reportDir, bName)); runtime.GOOS != "windows" && err != nil {
+		t.Errorf("Expected a link to the plugin executable in %s. Got: %s", reportDir, err.Error())
+	}
+}
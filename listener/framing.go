@@ -0,0 +1,113 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package listener
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+
+	"github.com/getgauge/html-report/gauge_messages"
+	"github.com/golang/protobuf/proto"
+)
+
+// maxMessageSize is the size of the largest message accepted from Gauge. Suite results carry
+// screenshots, so it allows for a large suite; larger messages are skipped.
+const maxMessageSize = 64 << 20
+
+// messageReader reads the varint length delimited messages Gauge sends to plugins.
+type messageReader struct {
+	r       *bufio.Reader
+	maxSize uint64
+}
+
+func newMessageReader(r io.Reader, maxSize uint64) *messageReader {
+	return &messageReader{r: bufio.NewReader(r), maxSize: maxSize}
+}
+
+var errCorruptLength = errors.New("length prefix overflows a 64-bit integer")
+
+// readLength reads a varint length prefix.
+func (mr *messageReader) readLength() (uint64, error) {
+	var length uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		b, err := mr.r.ReadByte()
+		if err != nil {
+			if shift > 0 && err == io.EOF {
+				return 0, io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		length |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return length, nil
+		}
+	}
+	return 0, errCorruptLength
+}
+
+// next returns the next message of the stream. Messages that can not be unmarshalled are
+// skipped, as their length still tells where the next one starts. So are messages beyond the
+// maximum size, which are discarded without being buffered. A length prefix that overflows
+// can not be trusted, so the bytes after it are read as the next length prefix, until a
+// message can be read again. Empty messages are skipped. The buffer of a message grows as
+// its bytes arrive, so a corrupt length within the maximum size does not allocate more than
+// was sent. An error is only returned once the stream can not be read any more.
+func (mr *messageReader) next() (*gauge_messages.Message, error) {
+	corrupt := false
+	for {
+		length, err := mr.readLength()
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, io.EOF
+		}
+		if err != nil && err != errCorruptLength {
+			return nil, err
+		}
+		if err == errCorruptLength {
+			if !corrupt {
+				log.Printf("[Warning] Corrupt message from Gauge, skipping to the next readable message\n")
+				corrupt = true
+			}
+			continue
+		}
+		if length > mr.maxSize {
+			log.Printf("[Warning] Skipping message of %d bytes from Gauge, it exceeds the limit of %d bytes. "+
+				"If it is the suite result, the report is not generated.\n", length, mr.maxSize)
+			if _, err := io.CopyN(ioutil.Discard, mr.r, int64(length)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if length == 0 {
+			continue
+		}
+		var b bytes.Buffer
+		if _, err := io.CopyN(&b, mr.r, int64(length)); err != nil {
+			return nil, err
+		}
+		message := &gauge_messages.Message{}
+		if err := proto.Unmarshal(b.Bytes(), message); err != nil {
+			log.Printf("Failed to read proto message: %s\n", err.Error())
+			continue
+		}
+		return message, nil
+	}
+}
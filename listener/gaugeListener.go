@@ -18,21 +18,28 @@
 package listener
 
 import (
-	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"log"
 	"net"
-	"os"
+	"sync"
 
 	"github.com/getgauge/html-report/gauge_messages"
-	"github.com/golang/protobuf/proto"
 )
 
 type GaugeResultHandlerFn func(*gauge_messages.SuiteExecutionResult)
 
+// ExecutionInfoHandlerFn handles an execution event with the info Gauge sends along with it.
+type ExecutionInfoHandlerFn func(*gauge_messages.ExecutionInfo)
+
+// GaugeListener dispatches the messages Gauge sends to the handlers registered on it.
+// Handlers are to be registered before Start is called.
 type GaugeListener struct {
-	connection      net.Conn
-	onResultHandler GaugeResultHandlerFn
+	connection     net.Conn
+	resultHandlers []GaugeResultHandlerFn
+	eventHandlers  map[gauge_messages.Message_MessageType][]ExecutionInfoHandlerFn
+	inFlight       sync.WaitGroup
 }
 
 func NewGaugeListener(host string, port string) (*GaugeListener, error) {
@@ -44,48 +51,162 @@ func NewGaugeListener(host string, port string) (*GaugeListener, error) {
 	}
 }
 
+// OnSuiteResult adds a handler for the result of the suite, sent once the execution is over.
+// Suite result handlers run concurrently with the messages that follow.
 func (gaugeListener *GaugeListener) OnSuiteResult(resultHandler GaugeResultHandlerFn) {
-	gaugeListener.onResultHandler = resultHandler
+	gaugeListener.resultHandlers = append(gaugeListener.resultHandlers, resultHandler)
+}
+
+// OnExecutionStarting adds a handler for the start of the suite execution.
+func (gaugeListener *GaugeListener) OnExecutionStarting(handler ExecutionInfoHandlerFn) {
+	gaugeListener.on(gauge_messages.Message_ExecutionStarting, handler)
+}
+
+// OnExecutionEnding adds a handler for the end of the suite execution.
+func (gaugeListener *GaugeListener) OnExecutionEnding(handler ExecutionInfoHandlerFn) {
+	gaugeListener.on(gauge_messages.Message_ExecutionEnding, handler)
+}
+
+// OnSpecStarting adds a handler for the start of each spec execution.
+func (gaugeListener *GaugeListener) OnSpecStarting(handler ExecutionInfoHandlerFn) {
+	gaugeListener.on(gauge_messages.Message_SpecExecutionStarting, handler)
+}
+
+// OnSpecEnding adds a handler for the end of each spec execution.
+func (gaugeListener *GaugeListener) OnSpecEnding(handler ExecutionInfoHandlerFn) {
+	gaugeListener.on(gauge_messages.Message_SpecExecutionEnding, handler)
+}
+
+// OnScenarioStarting adds a handler for the start of each scenario execution.
+func (gaugeListener *GaugeListener) OnScenarioStarting(handler ExecutionInfoHandlerFn) {
+	gaugeListener.on(gauge_messages.Message_ScenarioExecutionStarting, handler)
+}
+
+// OnScenarioEnding adds a handler for the end of each scenario execution.
+func (gaugeListener *GaugeListener) OnScenarioEnding(handler ExecutionInfoHandlerFn) {
+	gaugeListener.on(gauge_messages.Message_ScenarioExecutionEnding, handler)
+}
+
+// OnStepStarting adds a handler for the start of each step execution.
+func (gaugeListener *GaugeListener) OnStepStarting(handler ExecutionInfoHandlerFn) {
+	gaugeListener.on(gauge_messages.Message_StepExecutionStarting, handler)
+}
+
+// OnStepEnding adds a handler for the end of each step execution.
+func (gaugeListener *GaugeListener) OnStepEnding(handler ExecutionInfoHandlerFn) {
+	gaugeListener.on(gauge_messages.Message_StepExecutionEnding, handler)
+}
+
+func (gaugeListener *GaugeListener) on(messageType gauge_messages.Message_MessageType, handler ExecutionInfoHandlerFn) {
+	if gaugeListener.eventHandlers == nil {
+		gaugeListener.eventHandlers = make(map[gauge_messages.Message_MessageType][]ExecutionInfoHandlerFn)
+	}
+	gaugeListener.eventHandlers[messageType] = append(gaugeListener.eventHandlers[messageType], handler)
 }
 
-func (gaugeListener *GaugeListener) Start() {
-	buffer := new(bytes.Buffer)
-	data := make([]byte, 8192)
+// Start reads messages from Gauge until Gauge asks the plugin to stop, the connection is closed
+// or ctx is done. Suite results are handled while the following messages are read, and Start
+// only returns once all of them have been handled.
+func (gaugeListener *GaugeListener) Start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	messages := make(chan *gauge_messages.Message)
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- gaugeListener.readMessages(ctx, messages)
+	}()
+	defer gaugeListener.inFlight.Wait()
+	defer gaugeListener.connection.Close()
 	for {
-		n, err := gaugeListener.connection.Read(data)
-		if err != nil {
-			return
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case message := <-messages:
+			switch message.MessageType {
+			case gauge_messages.Message_KillProcessRequest:
+				return nil
+			case gauge_messages.Message_SuiteExecutionResult:
+				gaugeListener.handleSuiteResult(message.GetSuiteExecutionResult())
+			default:
+				gaugeListener.handleEvent(message)
+			}
 		}
-		buffer.Write(data[0:n])
-		gaugeListener.processMessages(buffer)
 	}
 }
 
-func (gaugeListener *GaugeListener) processMessages(buffer *bytes.Buffer) {
+func (gaugeListener *GaugeListener) readMessages(ctx context.Context, messages chan<- *gauge_messages.Message) error {
+	reader := newMessageReader(gaugeListener.connection, maxMessageSize)
 	for {
-		messageLength, bytesRead := proto.DecodeVarint(buffer.Bytes())
-		if messageLength > 0 && messageLength < uint64(buffer.Len()) {
-			message := &gauge_messages.Message{}
-			messageBoundary := int(messageLength) + bytesRead
-			err := proto.Unmarshal(buffer.Bytes()[bytesRead:messageBoundary], message)
-			if err != nil {
-				log.Printf("Failed to read proto message: %s\n", err.Error())
-			} else {
-				if message.MessageType == gauge_messages.Message_KillProcessRequest {
-					gaugeListener.connection.Close()
-					os.Exit(0)
-				}
-				if message.MessageType == gauge_messages.Message_SuiteExecutionResult {
-					result := message.GetSuiteExecutionResult()
-					gaugeListener.onResultHandler(result)
-				}
-				buffer.Next(messageBoundary)
-				if buffer.Len() == 0 {
-					return
-				}
-			}
-		} else {
-			return
+		message, err := reader.next()
+		if err != nil {
+			return err
 		}
+		select {
+		case messages <- message:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (gaugeListener *GaugeListener) handleSuiteResult(result *gauge_messages.SuiteExecutionResult) {
+	for _, handler := range gaugeListener.resultHandlers {
+		handler := handler
+		gaugeListener.inFlight.Add(1)
+		go func() {
+			defer gaugeListener.inFlight.Done()
+			defer recoverHandler(gauge_messages.Message_SuiteExecutionResult)
+			handler(result)
+		}()
+	}
+}
+
+// handleEvent calls the handlers of an execution event in the order they were added, before
+// the next message is read, so that they see the events in the order Gauge sent them.
+func (gaugeListener *GaugeListener) handleEvent(message *gauge_messages.Message) {
+	handlers := gaugeListener.eventHandlers[message.MessageType]
+	if len(handlers) == 0 {
+		return
+	}
+	info := executionInfo(message)
+	for _, handler := range handlers {
+		func() {
+			defer recoverHandler(message.MessageType)
+			handler(info)
+		}()
+	}
+}
+
+// recoverHandler stops a panicking handler from taking the plugin down with it.
+func recoverHandler(messageType gauge_messages.Message_MessageType) {
+	if r := recover(); r != nil {
+		log.Printf("[Warning] Handler for %s panicked: %v\n", messageType, r)
+	}
+}
+
+func executionInfo(message *gauge_messages.Message) *gauge_messages.ExecutionInfo {
+	switch message.MessageType {
+	case gauge_messages.Message_ExecutionStarting:
+		return message.GetExecutionStartingRequest().GetCurrentExecutionInfo()
+	case gauge_messages.Message_ExecutionEnding:
+		return message.GetExecutionEndingRequest().GetCurrentExecutionInfo()
+	case gauge_messages.Message_SpecExecutionStarting:
+		return message.GetSpecExecutionStartingRequest().GetCurrentExecutionInfo()
+	case gauge_messages.Message_SpecExecutionEnding:
+		return message.GetSpecExecutionEndingRequest().GetCurrentExecutionInfo()
+	case gauge_messages.Message_ScenarioExecutionStarting:
+		return message.GetScenarioExecutionStartingRequest().GetCurrentExecutionInfo()
+	case gauge_messages.Message_ScenarioExecutionEnding:
+		return message.GetScenarioExecutionEndingRequest().GetCurrentExecutionInfo()
+	case gauge_messages.Message_StepExecutionStarting:
+		return message.GetStepExecutionStartingRequest().GetCurrentExecutionInfo()
+	case gauge_messages.Message_StepExecutionEnding:
+		return message.GetStepExecutionEndingRequest().GetCurrentExecutionInfo()
 	}
+	return nil
 }
@@ -0,0 +1,283 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package listener
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getgauge/html-report/gauge_messages"
+	"github.com/golang/protobuf/proto"
+)
+
+func encode(t *testing.T, messages ...*gauge_messages.Message) []byte {
+	var b bytes.Buffer
+	for _, m := range messages {
+		data, err := proto.Marshal(m)
+		if err != nil {
+			t.Fatalf("failed to marshal message: %s", err.Error())
+		}
+		b.Write(proto.EncodeVarint(uint64(len(data))))
+		b.Write(data)
+	}
+	return b.Bytes()
+}
+
+func killMessage() *gauge_messages.Message {
+	return &gauge_messages.Message{MessageType: gauge_messages.Message_KillProcessRequest, KillProcessRequest: &gauge_messages.KillProcessRequest{}}
+}
+
+func suiteResultMessage(projectName string) *gauge_messages.Message {
+	return &gauge_messages.Message{
+		MessageType:          gauge_messages.Message_SuiteExecutionResult,
+		SuiteExecutionResult: &gauge_messages.SuiteExecutionResult{SuiteResult: &gauge_messages.ProtoSuiteResult{ProjectName: projectName}},
+	}
+}
+
+func readAll(t *testing.T, r io.Reader, maxSize uint64) []*gauge_messages.Message {
+	mr := newMessageReader(r, maxSize)
+	var messages []*gauge_messages.Message
+	for {
+		m, err := mr.next()
+		if err == io.EOF {
+			return messages
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+		messages = append(messages, m)
+	}
+}
+
+func TestMessageReaderReadsMessageFillingTheStream(t *testing.T) {
+	messages := readAll(t, bytes.NewReader(encode(t, suiteResultMessage("foo"))), maxMessageSize)
+
+	if len(messages) != 1 {
+		t.Fatalf("want: 1 message, got: %d", len(messages))
+	}
+	if got := messages[0].GetSuiteExecutionResult().GetSuiteResult().GetProjectName(); got != "foo" {
+		t.Errorf("want: %q, got: %q", "foo", got)
+	}
+}
+
+func TestMessageReaderReadsMessagesSplitAcrossReads(t *testing.T) {
+	data := encode(t, suiteResultMessage("foo"), killMessage())
+	client, server := net.Pipe()
+	go func() {
+		for _, b := range data {
+			server.Write([]byte{b})
+		}
+		server.Close()
+	}()
+
+	messages := readAll(t, client, maxMessageSize)
+
+	if len(messages) != 2 {
+		t.Fatalf("want: 2 messages, got: %d", len(messages))
+	}
+	if messages[1].MessageType != gauge_messages.Message_KillProcessRequest {
+		t.Errorf("want: %s, got: %s", gauge_messages.Message_KillProcessRequest, messages[1].MessageType)
+	}
+}
+
+func TestMessageReaderSkipsEmptyMessages(t *testing.T) {
+	data := append([]byte{0, 0}, encode(t, killMessage())...)
+
+	messages := readAll(t, bytes.NewReader(data), maxMessageSize)
+
+	if len(messages) != 1 || messages[0].MessageType != gauge_messages.Message_KillProcessRequest {
+		t.Errorf("want: the kill request, got: %v", messages)
+	}
+}
+
+func TestMessageReaderSkipsMessagesThatCanNotBeUnmarshalled(t *testing.T) {
+	data := append([]byte{2, 0xff, 0xff}, encode(t, killMessage())...)
+
+	messages := readAll(t, bytes.NewReader(data), maxMessageSize)
+
+	if len(messages) != 1 || messages[0].MessageType != gauge_messages.Message_KillProcessRequest {
+		t.Errorf("want: the kill request, got: %v", messages)
+	}
+}
+
+func TestMessageReaderSkipsMessagesBeyondTheMaximumSize(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	oversized := encode(t, suiteResultMessage(strings.Repeat("a", 64)))
+	data := append(oversized, encode(t, killMessage())...)
+
+	messages := readAll(t, bytes.NewReader(data), uint64(len(oversized)-2))
+
+	if len(messages) != 1 || messages[0].MessageType != gauge_messages.Message_KillProcessRequest {
+		t.Errorf("want: the kill request, got: %v", messages)
+	}
+	want := fmt.Sprintf("Skipping message of %d bytes from Gauge, it exceeds the limit of %d bytes", len(oversized)-1, len(oversized)-2)
+	if !strings.Contains(logs.String(), want) {
+		t.Errorf("want: %q, got: %q", want, logs.String())
+	}
+}
+
+func TestStartWaitsForSuiteResultHandlersOnKill(t *testing.T) {
+	client, server := net.Pipe()
+	l := &GaugeListener{connection: client}
+	handled := make(chan string, 1)
+	l.OnSuiteResult(func(res *gauge_messages.SuiteExecutionResult) {
+		time.Sleep(50 * time.Millisecond)
+		handled <- res.GetSuiteResult().GetProjectName()
+	})
+	go server.Write(encode(t, suiteResultMessage("foo"), killMessage()))
+
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	select {
+	case got := <-handled:
+		if got != "foo" {
+			t.Errorf("want: %q, got: %q", "foo", got)
+		}
+	default:
+		t.Error("Start returned before the suite result was handled")
+	}
+}
+
+func TestStartReturnsWhenContextIsDone(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	l := &GaugeListener{connection: client}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := l.Start(ctx); err != context.Canceled {
+		t.Errorf("want: %v, got: %v", context.Canceled, err)
+	}
+}
+
+func TestStartReturnsWhenConnectionIsClosed(t *testing.T) {
+	client, server := net.Pipe()
+	l := &GaugeListener{connection: client}
+	server.Close()
+
+	if err := l.Start(context.Background()); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+}
+
+func specStartingMessage(fileName string) *gauge_messages.Message {
+	return &gauge_messages.Message{
+		MessageType: gauge_messages.Message_SpecExecutionStarting,
+		SpecExecutionStartingRequest: &gauge_messages.SpecExecutionStartingRequest{
+			CurrentExecutionInfo: &gauge_messages.ExecutionInfo{CurrentSpec: &gauge_messages.SpecInfo{FileName: fileName}},
+		},
+	}
+}
+
+func TestStartCallsEveryHandlerOfAnEventInOrder(t *testing.T) {
+	client, server := net.Pipe()
+	l := &GaugeListener{connection: client}
+	var got []string
+	l.OnSpecStarting(func(info *gauge_messages.ExecutionInfo) {
+		got = append(got, "first:"+info.GetCurrentSpec().GetFileName())
+	})
+	l.OnSpecStarting(func(info *gauge_messages.ExecutionInfo) {
+		got = append(got, "second:"+info.GetCurrentSpec().GetFileName())
+	})
+	l.OnSpecEnding(func(info *gauge_messages.ExecutionInfo) {
+		got = append(got, "ending")
+	})
+	go server.Write(encode(t, specStartingMessage("a.spec"), specStartingMessage("b.spec"), killMessage()))
+
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	want := []string{"first:a.spec", "second:a.spec", "first:b.spec", "second:b.spec"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
+
+func TestStartRecoversFromPanickingHandlers(t *testing.T) {
+	client, server := net.Pipe()
+	l := &GaugeListener{connection: client}
+	var specs []string
+	l.OnSpecStarting(func(info *gauge_messages.ExecutionInfo) {
+		panic("boom")
+	})
+	l.OnSpecStarting(func(info *gauge_messages.ExecutionInfo) {
+		specs = append(specs, info.GetCurrentSpec().GetFileName())
+	})
+	l.OnSuiteResult(func(res *gauge_messages.SuiteExecutionResult) {
+		panic("boom")
+	})
+	results := make(chan string, 1)
+	l.OnSuiteResult(func(res *gauge_messages.SuiteExecutionResult) {
+		results <- res.GetSuiteResult().GetProjectName()
+	})
+	go server.Write(encode(t, specStartingMessage("a.spec"), suiteResultMessage("foo"), killMessage()))
+
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if !reflect.DeepEqual(specs, []string{"a.spec"}) {
+		t.Errorf("want: %v, got: %v", []string{"a.spec"}, specs)
+	}
+	if got := <-results; got != "foo" {
+		t.Errorf("want: %q, got: %q", "foo", got)
+	}
+}
+
+func TestMessageReaderReturnsReadErrors(t *testing.T) {
+	client, server := net.Pipe()
+	server.Close()
+	client.Close()
+
+	if _, err := newMessageReader(client, maxMessageSize).next(); err == nil || err == io.EOF {
+		t.Errorf("want: the read error, got: %v", err)
+	}
+}
+
+func TestMessageReaderStopsAtTruncatedMessage(t *testing.T) {
+	data := append(proto.EncodeVarint(maxMessageSize), 1, 2, 3)
+
+	if _, err := newMessageReader(bytes.NewReader(data), maxMessageSize).next(); err != io.EOF {
+		t.Errorf("want: %v, got: %v", io.EOF, err)
+	}
+}
+
+func TestMessageReaderResynchronisesAfterOverflowingLength(t *testing.T) {
+	overflow := bytes.Repeat([]byte{0xff}, 10)
+	data := append(overflow, encode(t, killMessage())...)
+
+	messages := readAll(t, bytes.NewReader(data), maxMessageSize)
+
+	if len(messages) != 1 || messages[0].MessageType != gauge_messages.Message_KillProcessRequest {
+		t.Errorf("want: the kill request, got: %v", messages)
+	}
+}
@@ -18,35 +18,49 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"log"
 
 	"github.com/getgauge/common"
 	"github.com/getgauge/html-report/env"
+	"github.com/getgauge/html-report/generator"
 	"github.com/getgauge/html-report/regenerate"
+	"github.com/getgauge/html-report/server"
 	flag "github.com/getgauge/mflag"
 )
 
 var inputFile = flag.String([]string{"-input", "i"}, "", "Source file to generate report from. This should be generated in <PROJECTROOT>/.gauge folder.")
 var outDir = flag.String([]string{"-output", "o"}, "", "Output location for generating report. Will create directory if it doesn't exist.")
 var themePath = flag.String([]string{"-theme", "t"}, "", "Theme to use for generating html report. 'default' theme will be used if not specified.")
+var formats = flag.String([]string{"-formats", "f"}, "", "Comma separated list of report formats to generate, e.g. html,json. Formats set in html_report_formats property will be used if not specified.")
+var serveDir = flag.String([]string{"-serve", "s"}, "", "Serve the report in the given directory over http. The saved result given with --input backs the /api/result.json endpoint.")
+var port = flag.Int([]string{"-port", "p"}, 0, "Port to serve the report on. A free port will be used if not specified.")
+var watch = flag.Bool([]string{"-watch", "w"}, false, "Regenerate the report when the input or the theme changes. Pages of a served report are reloaded when it is regenerated.")
 
 func main() {
 	flag.Parse()
+	if *serveDir != "" {
+		var result *server.Result
+		if *inputFile != "" && *outDir != "" {
+			if *watch {
+				// the server serves the result the watcher converts, instead of converting it concurrently
+				result = &server.Result{}
+				go regenerateReport(result.Set)
+			} else {
+				regenerateReport(nil)
+			}
+		}
+		serveReport(result)
+		return
+	}
 	if *inputFile != "" {
 		if *outDir == "" {
 			flag.PrintDefaults()
 			os.Exit(1)
 		}
-		projectRoot, err := common.GetProjectRoot()
-		if err != nil {
-			log.Fatalf("%s", err.Error())
-		}
-		if !common.FileExists(*inputFile) {
-			log.Fatalf("Input file does not exist: %s", *inputFile)
-		}
-		regenerate.Report(*inputFile, *outDir, *themePath, projectRoot)
+		regenerateReport(nil)
 		return
 	}
 
@@ -54,6 +68,41 @@ func main() {
 	if action == setupAction {
 		env.AddDefaultPropertiesToProject()
 	} else if action == executionAction {
-		createExecutionReport()
+		if err := createExecutionReport(); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+}
+
+func regenerateReport(onResult func(*generator.SuiteResult)) {
+	projectRoot, err := common.GetProjectRoot()
+	if err != nil {
+		log.Fatalf("%s", err.Error())
+	}
+	if !common.FileExists(*inputFile) {
+		log.Fatalf("Input file does not exist: %s", *inputFile)
+	}
+	if *watch {
+		regenerate.Watch(*inputFile, *outDir, *themePath, projectRoot, env.ParseReportFormats(*formats), onResult)
+		return
+	}
+	regenerate.Report(*inputFile, *outDir, *themePath, projectRoot, env.ParseReportFormats(*formats))
+}
+
+func serveReport(result *server.Result) {
+	opts := server.Options{Addr: fmt.Sprintf("localhost:%d", *port), Watch: *watch, InputFile: *inputFile, Result: result}
+	if *inputFile != "" {
+		if !common.FileExists(*inputFile) {
+			log.Fatalf("Input file does not exist: %s", *inputFile)
+		}
+		projectRoot, err := common.GetProjectRoot()
+		if err != nil {
+			log.Fatalf("%s", err.Error())
+		}
+		opts.ProjectRoot = projectRoot
+	}
+	if err := server.Serve(*serveDir, opts); err != nil {
+		log.Fatalf("%s", err.Error())
 	}
 }
@@ -0,0 +1,291 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package model holds the report model that Gauge execution results are converted to.
+//
+// A SuiteResult contains SpecResults, each Spec contains Scenarios and each Scenario
+// holds Items (steps, concepts and comments) in its Contexts, Items and Teardowns.
+// Concepts hold further Items. Use Walk to visit all of them in order.
+package model
+
+// Status is the execution status of a spec, scenario, step or data table row.
+type Status string
+
+// TokenKind identifies the type of an Item.
+type TokenKind string
+
+// ErrorType classifies a BuildError or a step failure.
+type ErrorType string
+
+const (
+	// Pass is the status of a passing spec, scenario, step or row.
+	Pass Status = "pass"
+	// Fail is the status of a failing spec, scenario, step or row.
+	Fail Status = "fail"
+	// Skip is the status of a skipped spec, scenario, step or row.
+	Skip Status = "skip"
+	// NotExecuted is the status of a step that was never run.
+	NotExecuted Status = "not executed"
+	// RecoverableFail is the status of a step that failed with continue on failure, so that the
+	// following steps were still run. Concepts and scenarios get it when all their failures were recoverable.
+	RecoverableFail Status = "recoverable fail"
+
+	// StepKind marks an Item holding a Step.
+	StepKind TokenKind = "step"
+	// ConceptKind marks an Item holding a Concept.
+	ConceptKind TokenKind = "concept"
+	// CommentKind marks an Item holding a Comment.
+	CommentKind TokenKind = "comment"
+
+	// AssertionErrorType is the error type of a failed assertion in a step.
+	AssertionErrorType ErrorType = "assertion"
+	// VerificationErrorType is the error type of a failed verification in a step.
+	VerificationErrorType ErrorType = "verification"
+	// ParseErrorType is the error type of a spec that could not be parsed.
+	ParseErrorType ErrorType = "parse"
+	// ValidationErrorType is the error type of a spec that failed validation.
+	ValidationErrorType ErrorType = "validation"
+)
+
+// FragmentKind identifies the type of a Fragment.
+type FragmentKind int
+
+const (
+	// TextFragmentKind is plain step text.
+	TextFragmentKind FragmentKind = iota
+	// StaticFragmentKind is a static parameter.
+	StaticFragmentKind
+	// DynamicFragmentKind is a dynamic parameter.
+	DynamicFragmentKind
+	// SpecialStringFragmentKind is a special parameter read from a file.
+	SpecialStringFragmentKind
+	// SpecialTableFragmentKind is a special table parameter read from a csv file.
+	SpecialTableFragmentKind
+	// TableFragmentKind is an inline table parameter.
+	TableFragmentKind
+)
+
+// SuiteResult holds the aggregated execution information for a run. ProjectRoot is the
+// directory the spec file names are relative to in the report.
+type SuiteResult struct {
+	ProjectName            string       `json:"ProjectName"`
+	Timestamp              string       `json:"Timestamp"`
+	SuccessRate            float32      `json:"SuccessRate"`
+	Environment            string       `json:"Environment"`
+	Tags                   string       `json:"Tags"`
+	ExecutionTime          int64        `json:"ExecutionTime"`
+	ExecutionStatus        Status       `json:"ExecutionStatus"`
+	SpecResults            []*Spec      `json:"SpecResults"`
+	BeforeSuiteHookFailure *HookFailure `json:"BeforeSuiteHookFailure"`
+	AfterSuiteHookFailure  *HookFailure `json:"AfterSuiteHookFailure"`
+	PassedSpecsCount       int          `json:"PassedSpecsCount"`
+	FailedSpecsCount       int          `json:"FailedSpecsCount"`
+	SkippedSpecsCount      int          `json:"SkippedSpecsCount"`
+	BasePath               string       `json:"BasePath"`
+	Build                  *BuildInfo   `json:"Build"`
+	ProjectRoot            string       `json:"-"`
+}
+
+// BuildInfo describes the code and the CI job a report was generated for.
+// Fields that could not be determined are left empty.
+type BuildInfo struct {
+	Commit      string `json:"Commit"`
+	Branch      string `json:"Branch"`
+	Dirty       bool   `json:"Dirty"`
+	CI          string `json:"CI"`
+	BuildNumber string `json:"BuildNumber"`
+	JobURL      string `json:"JobURL"`
+	AgentName   string `json:"AgentName"`
+}
+
+// ShortCommit returns the abbreviated commit hash.
+func (b *BuildInfo) ShortCommit() string {
+	if len(b.Commit) > 7 {
+		return b.Commit[:7]
+	}
+	return b.Commit
+}
+
+// Spec holds the execution result of a specification file. SourceURL links to the spec file in
+// the project's source repository, if configured.
+type Spec struct {
+	CommentsBeforeDatatable  []string       `json:"CommentsBeforeDatatable"`
+	CommentsAfterDatatable   []string       `json:"CommentsAfterDatatable"`
+	SpecHeading              string         `json:"SpecHeading"`
+	FileName                 string         `json:"FileName"`
+	Tags                     []string       `json:"Tags"`
+	ExecutionTime            int64          `json:"ExecutionTime"`
+	ExecutionStatus          Status         `json:"ExecutionStatus"`
+	Scenarios                []*Scenario    `json:"Scenarios"`
+	IsTableDriven            bool           `json:"IsTableDriven"`
+	Datatable                *Table         `json:"Datatable"`
+	BeforeSpecHookFailures   []*HookFailure `json:"BeforeSpecHookFailures"`
+	AfterSpecHookFailures    []*HookFailure `json:"AfterSpecHookFailures"`
+	PassedScenarioCount      int            `json:"PassedScenarioCount"`
+	FailedScenarioCount      int            `json:"FailedScenarioCount"`
+	SkippedScenarioCount     int            `json:"SkippedScenarioCount"`
+	RecoverableScenarioCount int            `json:"RecoverableScenarioCount"`
+	Errors                   []BuildError   `json:"Errors"`
+	SourceURL                string         `json:"SourceURL"`
+}
+
+// Scenario holds the execution result of a scenario. TableRowIndex is -1 unless
+// the scenario was run for a row of the spec's data table. ID is the identifier
+// Gauge assigned to the scenario and Anchor is the id of the scenario's element on the spec page.
+// SourceURL links to the scenario in the project's source repository, if configured.
+// Position is the index of the scenario in its spec and Duration its execution time in milliseconds.
+type Scenario struct {
+	Heading                   string       `json:"Heading"`
+	Tags                      []string     `json:"Tags"`
+	ExecutionTime             string       `json:"ExecutionTime"`
+	ExecutionStatus           Status       `json:"ExecutionStatus"`
+	Contexts                  []Item       `json:"Contexts"`
+	Teardowns                 []Item       `json:"Teardowns"`
+	Items                     []Item       `json:"Items"`
+	BeforeScenarioHookFailure *HookFailure `json:"BeforeScenarioHookFailure"`
+	AfterScenarioHookFailure  *HookFailure `json:"AfterScenarioHookFailure"`
+	SkipErrors                []string     `json:"SkipErrors"`
+	TableRowIndex             int          `json:"TableRowIndex"`
+	ID                        string       `json:"ID"`
+	Span                      *Span        `json:"Span"`
+	Anchor                    string       `json:"Anchor"`
+	SourceURL                 string       `json:"SourceURL"`
+	Position                  int          `json:"Position"`
+	Duration                  int64        `json:"Duration"`
+}
+
+// Span holds the first and last line of a scenario in its spec file.
+type Span struct {
+	Start int64 `json:"Start"`
+	End   int64 `json:"End"`
+}
+
+// Step holds the execution result of a step. Anchor is the id of the step's element on the spec page.
+type Step struct {
+	Fragments             []*Fragment  `json:"Fragments"`
+	ItemType              TokenKind    `json:"ItemType"`
+	StepText              string       `json:"StepText"`
+	Table                 *Table       `json:"Table"`
+	BeforeStepHookFailure *HookFailure `json:"BeforeStepHookFailure"`
+	AfterStepHookFailure  *HookFailure `json:"AfterStepHookFailure"`
+	Result                *Result      `json:"Result"`
+	Anchor                string       `json:"Anchor"`
+}
+
+// Kind returns StepKind.
+func (s *Step) Kind() TokenKind {
+	return StepKind
+}
+
+// Result is the outcome of executing a step or concept. Duration is the execution time in milliseconds.
+type Result struct {
+	Status        Status    `json:"Status"`
+	StackTrace    string    `json:"StackTrace"`
+	Screenshot    string    `json:"Screenshot"`
+	ErrorMessage  string    `json:"ErrorMessage"`
+	ExecutionTime string    `json:"ExecutionTime"`
+	SkippedReason string    `json:"SkippedReason"`
+	Messages      []string  `json:"Messages"`
+	ErrorType     ErrorType `json:"ErrorType"`
+	Duration      int64     `json:"Duration"`
+}
+
+// HookFailure holds the failure of an execution hook. TableRowIndex is -1 unless
+// the hook failed for a row of the spec's data table.
+type HookFailure struct {
+	HookName      string `json:"HookName"`
+	ErrMsg        string `json:"ErrMsg"`
+	Screenshot    string `json:"Screenshot"`
+	StackTrace    string `json:"StackTrace"`
+	TableRowIndex int32  `json:"TableRowIndex"`
+}
+
+// Concept holds the execution result of a concept and of the items it is made of.
+type Concept struct {
+	ItemType    TokenKind `json:"ItemType"`
+	ConceptStep *Step     `json:"ConceptStep"`
+	Items       []Item    `json:"Items"`
+	Result      Result    `json:"Result"`
+}
+
+// Kind returns ConceptKind.
+func (s *Concept) Kind() TokenKind {
+	return ConceptKind
+}
+
+// Table is a data table or a table parameter.
+type Table struct {
+	Headers []string `json:"Headers"`
+	Rows    []*Row   `json:"Rows"`
+}
+
+// Row is a row of a Table along with its execution status. For rows of a spec's data table,
+// ExecutionTime is the time taken by the scenarios executed for the row.
+type Row struct {
+	Cells         []string `json:"Cells"`
+	Result        Status   `json:"Status"`
+	ExecutionTime string   `json:"ExecutionTime"`
+}
+
+// BuildError is a parse or validation error reported for a spec. SourceURL links to the line
+// of the error in the project's source repository, if configured.
+type BuildError struct {
+	ErrorType  ErrorType
+	FileName   string
+	LineNumber int
+	Message    string
+	SourceURL  string
+}
+
+func (e BuildError) Error() string {
+	if e.IsParseError() {
+		return "[Parse Error] " + e.Message
+	}
+	return "[Validation Error] " + e.Message
+}
+
+// IsParseError reports whether the error stopped the spec from being parsed.
+func (e BuildError) IsParseError() bool {
+	return e.ErrorType == ParseErrorType
+}
+
+// Item is an entry of a scenario or concept. Only the field matching Kind is set.
+type Item struct {
+	Kind    TokenKind
+	Step    *Step
+	Concept *Concept
+	Comment *Comment
+}
+
+// Comment is free text written between steps.
+type Comment struct {
+	Text string
+}
+
+// Kind returns CommentKind.
+func (c *Comment) Kind() TokenKind {
+	return CommentKind
+}
+
+// Fragment is a part of a step's text, either plain text or a parameter.
+type Fragment struct {
+	FragmentKind FragmentKind
+	Text         string
+	Name         string
+	Table        *Table
+	FileName     string
+}
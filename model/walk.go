@@ -0,0 +1,111 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package model
+
+// Visitor is called by Walk for every node of a SuiteResult.
+// Returning false from VisitSpec, VisitScenario or VisitItem skips the children of that node.
+type Visitor interface {
+	VisitSpec(s *Spec) bool
+	VisitScenario(s *Spec, scn *Scenario) bool
+	// VisitItem is called for the items of a scenario and for the items of each concept.
+	// The concept step of a concept is reported as part of the concept item.
+	VisitItem(scn *Scenario, i Item) bool
+}
+
+// VisitorFuncs adapts plain functions to a Visitor. Nil functions visit everything.
+type VisitorFuncs struct {
+	Spec     func(s *Spec) bool
+	Scenario func(s *Spec, scn *Scenario) bool
+	Item     func(scn *Scenario, i Item) bool
+}
+
+// VisitSpec calls f.Spec if set.
+func (f VisitorFuncs) VisitSpec(s *Spec) bool {
+	if f.Spec == nil {
+		return true
+	}
+	return f.Spec(s)
+}
+
+// VisitScenario calls f.Scenario if set.
+func (f VisitorFuncs) VisitScenario(s *Spec, scn *Scenario) bool {
+	if f.Scenario == nil {
+		return true
+	}
+	return f.Scenario(s, scn)
+}
+
+// VisitItem calls f.Item if set.
+func (f VisitorFuncs) VisitItem(scn *Scenario, i Item) bool {
+	if f.Item == nil {
+		return true
+	}
+	return f.Item(scn, i)
+}
+
+// Walk visits specs, scenarios and items of res in depth-first order.
+// Scenario items are visited contexts first, then the scenario's own items, then teardowns.
+func Walk(res *SuiteResult, v Visitor) {
+	if res == nil {
+		return
+	}
+	for _, s := range res.SpecResults {
+		WalkSpec(s, v)
+	}
+}
+
+// WalkSpec visits a single spec and everything below it.
+func WalkSpec(s *Spec, v Visitor) {
+	if s == nil || !v.VisitSpec(s) {
+		return
+	}
+	for _, scn := range s.Scenarios {
+		if !v.VisitScenario(s, scn) {
+			continue
+		}
+		walkItems(scn, scn.Contexts, v)
+		walkItems(scn, scn.Items, v)
+		walkItems(scn, scn.Teardowns, v)
+	}
+}
+
+func walkItems(scn *Scenario, items []Item, v Visitor) {
+	for _, i := range items {
+		if !v.VisitItem(scn, i) {
+			continue
+		}
+		if i.Kind == ConceptKind && i.Concept != nil {
+			walkItems(scn, i.Concept.Items, v)
+		}
+	}
+}
+
+// Steps returns all steps of a scenario in execution order, including the steps inside concepts.
+// Concept steps themselves are not included.
+func (scn *Scenario) Steps() []*Step {
+	steps := make([]*Step, 0)
+	WalkSpec(&Spec{Scenarios: []*Scenario{scn}}, VisitorFuncs{
+		Item: func(_ *Scenario, i Item) bool {
+			if i.Kind == StepKind {
+				steps = append(steps, i.Step)
+			}
+			return true
+		},
+	})
+	return steps
+}
@@ -0,0 +1,117 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newStepItem(text string) Item {
+	return Item{Kind: StepKind, Step: &Step{Fragments: []*Fragment{{FragmentKind: TextFragmentKind, Text: text}}}}
+}
+
+func newConceptItem(text string, items ...Item) Item {
+	return Item{Kind: ConceptKind, Concept: &Concept{ConceptStep: newStepItem(text).Step, Items: items}}
+}
+
+var walkResult = &SuiteResult{
+	SpecResults: []*Spec{
+		{
+			SpecHeading: "spec 1",
+			Scenarios: []*Scenario{
+				{
+					Heading:   "scenario 1",
+					Contexts:  []Item{newStepItem("context")},
+					Items:     []Item{newStepItem("step 1"), {Kind: CommentKind, Comment: &Comment{Text: "comment"}}, newConceptItem("concept", newStepItem("concept step 1"), newConceptItem("nested", newStepItem("nested step")))},
+					Teardowns: []Item{newStepItem("teardown")},
+				},
+			},
+		},
+		{
+			SpecHeading: "spec 2",
+			Scenarios:   []*Scenario{{Heading: "scenario 2", Items: []Item{newStepItem("step 2")}}},
+		},
+	},
+}
+
+func itemText(i Item) string {
+	switch i.Kind {
+	case StepKind:
+		return i.Step.Fragments[0].Text
+	case ConceptKind:
+		return i.Concept.ConceptStep.Fragments[0].Text
+	default:
+		return i.Comment.Text
+	}
+}
+
+func TestWalkVisitsAllNodesInOrder(t *testing.T) {
+	var got []string
+	Walk(walkResult, VisitorFuncs{
+		Spec:     func(s *Spec) bool { got = append(got, "spec:"+s.SpecHeading); return true },
+		Scenario: func(_ *Spec, scn *Scenario) bool { got = append(got, "scenario:"+scn.Heading); return true },
+		Item:     func(_ *Scenario, i Item) bool { got = append(got, string(i.Kind)+":"+itemText(i)); return true },
+	})
+
+	want := []string{
+		"spec:spec 1", "scenario:scenario 1",
+		"step:context", "step:step 1", "comment:comment", "concept:concept", "step:concept step 1", "concept:nested", "step:nested step", "step:teardown",
+		"spec:spec 2", "scenario:scenario 2", "step:step 2",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want:\n%q\ngot:\n%q\n", want, got)
+	}
+}
+
+func TestWalkSkipsChildrenWhenVisitReturnsFalse(t *testing.T) {
+	var got []string
+	Walk(walkResult, VisitorFuncs{
+		Spec: func(s *Spec) bool { return s.SpecHeading == "spec 1" },
+		Item: func(_ *Scenario, i Item) bool { got = append(got, itemText(i)); return i.Kind != ConceptKind },
+	})
+
+	want := []string{"context", "step 1", "comment", "concept", "teardown"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want:\n%q\ngot:\n%q\n", want, got)
+	}
+}
+
+func TestScenarioSteps(t *testing.T) {
+	var got []string
+	for _, s := range walkResult.SpecResults[0].Scenarios[0].Steps() {
+		got = append(got, s.Fragments[0].Text)
+	}
+
+	want := []string{"context", "step 1", "concept step 1", "nested step", "teardown"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want:\n%q\ngot:\n%q\n", want, got)
+	}
+}
+
+func TestBuildError(t *testing.T) {
+	parseErr := BuildError{ErrorType: ParseErrorType, Message: "foo"}
+	validationErr := BuildError{ErrorType: ValidationErrorType, Message: "bar"}
+
+	if !parseErr.IsParseError() || parseErr.Error() != "[Parse Error] foo" {
+		t.Errorf("Unexpected parse error: %s", parseErr.Error())
+	}
+	if validationErr.IsParseError() || validationErr.Error() != "[Validation Error] bar" {
+		t.Errorf("Unexpected validation error: %s", validationErr.Error())
+	}
+}
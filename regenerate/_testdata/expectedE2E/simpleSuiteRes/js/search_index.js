@@ -1 +1 @@
-var index = {"Tags":{"single word":["example.html"]},"Specs":{"Specification Heading":["example.html"]}};
\ No newline at end of file
+var index = {"Tags":{"single word":["example.html"]},"Specs":{"Specification Heading":["example.html"]},"Shards":{".":{"File":"search/0.js","Prefixes":["ae","al","ar","co","en","ga","ha","in","la","mu","si","ta","th","vo","wo"]}}};
\ No newline at end of file
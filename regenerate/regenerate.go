@@ -22,6 +22,7 @@ import (
 	"log"
 	"path/filepath"
 
+	"github.com/getgauge/html-report/buildinfo"
 	"github.com/getgauge/html-report/env"
 	"github.com/getgauge/html-report/gauge_messages"
 	"github.com/getgauge/html-report/generator"
@@ -29,8 +30,9 @@ import (
 	"github.com/golang/protobuf/proto"
 )
 
-// Report generates html report from saved result.
-func Report(inputFile, reportsDir, themePath, pRoot string) {
+// Report generates reports from saved result in the given formats.
+// The formats configured for the project are used when formats is empty.
+func Report(inputFile, reportsDir, themePath, pRoot string, formats []string) {
 	b, err := ioutil.ReadFile(inputFile)
 	if err != nil {
 		log.Fatal(err.Error())
@@ -40,12 +42,22 @@ func Report(inputFile, reportsDir, themePath, pRoot string) {
 	if err != nil {
 		log.Fatalf("Unable to read last run data from %s. Error: %s", inputFile, err.Error())
 	}
-	res := generator.ToSuiteResult(pRoot, psr)
+	res := generator.ToSuiteResultWithBuild(pRoot, psr, buildinfo.Load(inputFile))
 
 	env.CreateDirectory(reportsDir)
+	if len(formats) == 0 {
+		formats = env.GetReportFormats()
+	}
+	if err := generator.GenerateReport(res, reportsDir, resolveThemePath(themePath), formats); err != nil {
+		log.Fatal(err.Error())
+	}
+}
+
+// resolveThemePath returns the default theme of the plugin if no theme is given.
+func resolveThemePath(themePath string) string {
 	if themePath == "" {
 		workingDir, _ := env.GetCurrentExecutableDir()
 		themePath = theme.GetDefaultThemePath(filepath.Dir(workingDir))
 	}
-	generator.GenerateReport(res, reportsDir, themePath)
+	return themePath
 }
@@ -0,0 +1,258 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package regenerate
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/getgauge/html-report/buildinfo"
+	"github.com/getgauge/html-report/env"
+	"github.com/getgauge/html-report/gauge_messages"
+	"github.com/getgauge/html-report/generator"
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	htmlFormat    = "html"
+	pollInterval  = time.Second
+	partialsFile  = "partials.tmpl"
+	errorPageFile = "index.html"
+)
+
+// change is a bit set of the inputs of a report that changed.
+type change int
+
+const (
+	inputChanged change = 1 << iota
+	templatesChanged
+	assetsChanged
+)
+
+// errorPage shows why a report could not be regenerated in place of its index page.
+var errorPage = template.Must(template.New("error").Parse(`<!doctype html>
+<html>
+<head><meta charset="utf-8" /><title>Report generation failed</title></head>
+<body>
+  <h2>Report generation failed</h2>
+  <pre style="color: #e73e48; white-space: pre-wrap;">{{.}}</pre>
+  <p>The report will be regenerated once the input or the theme changes.</p>
+</body>
+</html>
+`))
+
+type watcher struct {
+	inputFile  string
+	reportsDir string
+	themePath  string
+	pRoot      string
+	formats    []string
+	res        *generator.SuiteResult
+	onResult   func(*generator.SuiteResult)
+	input      fileState
+	templates  fileState
+	assets     map[string]fileState
+	// changedAssets are the assets that changed since the last regeneration.
+	changedAssets []string
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// Watch generates reports like Report and regenerates them whenever the input file or
+// the theme changes, until the process is stopped. Only the parts of the report affected
+// by a change are regenerated: a changed input renders all formats, changed templates
+// render the html pages and changed assets are copied. Errors are shown in place of the
+// report, instead of stopping. onResult, if not nil, is given each result converted from the
+// input file.
+func Watch(inputFile, reportsDir, themePath, pRoot string, formats []string, onResult func(*generator.SuiteResult)) {
+	env.CreateDirectory(reportsDir)
+	w := &watcher{
+		inputFile:  inputFile,
+		reportsDir: reportsDir,
+		themePath:  resolveThemePath(themePath),
+		pRoot:      pRoot,
+		formats:    formats,
+		onResult:   onResult,
+	}
+	if len(w.formats) == 0 {
+		w.formats = env.GetReportFormats()
+	}
+	w.changes()
+	w.regenerate(inputChanged)
+	fmt.Printf("Watching %s and %s for changes\n", inputFile, w.themePath)
+	for range time.Tick(pollInterval) {
+		if c := w.changes(); c != 0 {
+			w.regenerate(c)
+		}
+	}
+}
+
+// changes tells what changed since it was last called.
+func (w *watcher) changes() change {
+	var c change
+	if s := statFile(w.inputFile); s != w.input {
+		w.input = s
+		c |= inputChanged
+	}
+	if s := statFile(filepath.Join(w.themePath, "views", partialsFile)); s != w.templates {
+		w.templates = s
+		c |= templatesChanged
+	}
+	assets := statDir(filepath.Join(w.themePath, "assets"))
+	if w.changedAssets = changedFiles(w.assets, assets); len(w.changedAssets) > 0 {
+		c |= assetsChanged
+	}
+	w.assets = assets
+	if !w.hasHTML() {
+		c &^= templatesChanged | assetsChanged
+	}
+	return c
+}
+
+func (w *watcher) regenerate(c change) {
+	var err error
+	if c&inputChanged != 0 {
+		err = w.generateAll()
+	} else {
+		if c&templatesChanged != 0 {
+			err = w.generateHTML()
+		}
+		if c&assetsChanged != 0 && err == nil {
+			err = w.copyAssets()
+		}
+	}
+	if err != nil {
+		log.Printf("[Warning] Failed to regenerate report: %s\n", err.Error())
+		w.writeErrorPage(err)
+		return
+	}
+	fmt.Printf("Regenerated report in %s\n", w.reportsDir)
+}
+
+func (w *watcher) generateAll() error {
+	b, err := ioutil.ReadFile(w.inputFile)
+	if err != nil {
+		return err
+	}
+	psr := &gauge_messages.ProtoSuiteResult{}
+	if err = proto.Unmarshal(b, psr); err != nil {
+		return fmt.Errorf("unable to read last run data from %s. Error: %s", w.inputFile, err.Error())
+	}
+	w.res = generator.ToSuiteResultWithBuild(w.pRoot, psr, buildinfo.Load(w.inputFile))
+	if w.onResult != nil {
+		w.onResult(w.res)
+	}
+	return generator.GenerateReport(w.res, w.reportsDir, w.themePath, w.formats)
+}
+
+func (w *watcher) generateHTML() error {
+	if w.res == nil {
+		return w.generateAll()
+	}
+	return generator.GenerateReports(w.res, w.reportsDir, w.themePath)
+}
+
+// copyAssets copies the assets of the theme that changed.
+func (w *watcher) copyAssets() error {
+	dir := filepath.Join(w.themePath, "assets")
+	for _, rel := range w.changedAssets {
+		if err := copyFile(filepath.Join(dir, rel), filepath.Join(w.reportsDir, rel)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *watcher) hasHTML() bool {
+	for _, f := range w.formats {
+		if f == htmlFormat {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *watcher) writeErrorPage(err error) {
+	f, ferr := os.Create(filepath.Join(w.reportsDir, errorPageFile))
+	if ferr != nil {
+		log.Printf("[Warning] Failed to write error page: %s\n", ferr.Error())
+		return
+	}
+	defer f.Close()
+	errorPage.Execute(f, err.Error())
+}
+
+func statFile(p string) fileState {
+	fi, err := os.Stat(p)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{size: fi.Size(), modTime: fi.ModTime()}
+}
+
+// statDir returns the state of the files below dir, by their path relative to dir.
+func statDir(dir string) map[string]fileState {
+	files := make(map[string]fileState)
+	filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, p)
+		files[rel] = fileState{size: fi.Size(), modTime: fi.ModTime()}
+		return nil
+	})
+	return files
+}
+
+// changedFiles lists the files of now that are new or changed since before.
+func changedFiles(before, now map[string]fileState) []string {
+	var changed []string
+	for p, s := range now {
+		if before[p] != s {
+			changed = append(changed, p)
+		}
+	}
+	return changed
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	env.CreateDirectory(filepath.Dir(dst))
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,151 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package regenerate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getgauge/html-report/env"
+)
+
+func newTestWatcher(t *testing.T) (*watcher, func()) {
+	tmp, err := ioutil.TempDir("", "html-report-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	themeDir := filepath.Join(tmp, "theme")
+	filepath.Walk(templateBasePath, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(templateBasePath, p)
+		return copyFile(p, filepath.Join(themeDir, rel))
+	})
+	w := &watcher{
+		inputFile:  filepath.Join("_testdata", "last_run_result"),
+		reportsDir: filepath.Join(tmp, "report"),
+		themePath:  themeDir,
+		formats:    []string{htmlFormat},
+	}
+	env.CreateDirectory(w.reportsDir)
+	w.changes()
+	w.regenerate(inputChanged)
+	return w, func() {
+		os.RemoveAll(tmp)
+	}
+}
+
+// touch rewrites a file of the theme with a modification time that differs from the previous one,
+// irrespective of the resolution of the file system clock.
+func touch(t *testing.T, p string, content string) {
+	if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(p, later, later)
+}
+
+func readReportFile(t *testing.T, w *watcher, name string) string {
+	b, err := ioutil.ReadFile(filepath.Join(w.reportsDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestWatcherRendersHTMLWhenTemplatesChange(t *testing.T) {
+	w, cleanUp := newTestWatcher(t)
+	defer cleanUp()
+	partials := filepath.Join(w.themePath, "views", partialsFile)
+	b, _ := ioutil.ReadFile(partials)
+	touch(t, partials, strings.Replace(string(b), "Generated by Gauge HTML Report", "Generated while watching", -1))
+
+	c := w.changes()
+	if c != templatesChanged {
+		t.Fatalf("want: %d, got: %d", templatesChanged, c)
+	}
+	w.regenerate(c)
+
+	if !strings.Contains(readReportFile(t, w, "index.html"), "Generated while watching") {
+		t.Errorf("want index.html to be rendered with the changed template")
+	}
+}
+
+func TestWatcherShowsTemplateErrorsInReport(t *testing.T) {
+	w, cleanUp := newTestWatcher(t)
+	defer cleanUp()
+	partials := filepath.Join(w.themePath, "views", partialsFile)
+	b, _ := ioutil.ReadFile(partials)
+	touch(t, partials, string(b)+`{{define "broken"}}{{.Heading`)
+
+	w.regenerate(w.changes())
+
+	got := readReportFile(t, w, errorPageFile)
+	if !strings.Contains(got, "Report generation failed") || !strings.Contains(got, "unclosed action") {
+		t.Errorf("want the template error in the report, got %q", got)
+	}
+}
+
+func TestWatcherCopiesOnlyChangedAssets(t *testing.T) {
+	w, cleanUp := newTestWatcher(t)
+	defer cleanUp()
+	os.Remove(filepath.Join(w.reportsDir, "js", "main.js"))
+	touch(t, filepath.Join(w.themePath, "assets", "css", "style.css"), "body { color: red; }")
+
+	c := w.changes()
+	if c != assetsChanged {
+		t.Fatalf("want: %d, got: %d", assetsChanged, c)
+	}
+	w.regenerate(c)
+
+	if got := readReportFile(t, w, filepath.Join("css", "style.css")); got != "body { color: red; }" {
+		t.Errorf("want: %q, got: %q", "body { color: red; }", got)
+	}
+	if _, err := os.Stat(filepath.Join(w.reportsDir, "js", "main.js")); !os.IsNotExist(err) {
+		t.Errorf("want unchanged assets to not be copied again")
+	}
+}
+
+func TestWatcherReportsNoChanges(t *testing.T) {
+	w, cleanUp := newTestWatcher(t)
+	defer cleanUp()
+
+	if c := w.changes(); c != 0 {
+		t.Errorf("want no changes, got: %d", c)
+	}
+}
+
+func TestWatcherShowsEveryFailingFormatInReport(t *testing.T) {
+	w, cleanUp := newTestWatcher(t)
+	defer cleanUp()
+	w.formats = []string{"first", htmlFormat, "second"}
+
+	w.regenerate(inputChanged)
+
+	got := readReportFile(t, w, errorPageFile)
+	for _, want := range []string{"unknown report format &#39;first&#39;", "unknown report format &#39;second&#39;"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %q in the report, got %q", want, got)
+		}
+	}
+}
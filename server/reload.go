@@ -0,0 +1,122 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// reloader pushes a reload event to the pages that listen for server sent events.
+type reloader struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]bool
+}
+
+func newReloader() *reloader {
+	return &reloader{clients: make(map[chan struct{}]bool)}
+}
+
+func (rl *reloader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	c := make(chan struct{}, 1)
+	rl.mu.Lock()
+	rl.clients[c] = true
+	rl.mu.Unlock()
+	defer func() {
+		rl.mu.Lock()
+		delete(rl.clients, c)
+		rl.mu.Unlock()
+	}()
+	fmt.Fprint(w, ": connected\n\n")
+	f.Flush()
+	for {
+		select {
+		case <-c:
+			fmt.Fprint(w, "data: reload\n\n")
+			f.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// notify tells all listening pages to reload. Pages that have a reload pending are not told twice.
+func (rl *reloader) notify() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for c := range rl.clients {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// watch polls dir for changes and calls changed once the files have stopped changing,
+// so that a report is not reloaded while it is still being written.
+func watch(dir string, interval time.Duration, changed func()) {
+	last := snapshot(dir)
+	pending := false
+	for range time.Tick(interval) {
+		s := snapshot(dir)
+		if s != last {
+			last = s
+			pending = true
+			continue
+		}
+		if pending {
+			pending = false
+			changed()
+		}
+	}
+}
+
+type dirState struct {
+	files   int
+	size    int64
+	modTime time.Time
+}
+
+// snapshot summarizes the files below dir. Regenerating a report changes at least the
+// modification time of the files written.
+func snapshot(dir string) dirState {
+	var s dirState
+	filepath.Walk(dir, func(_ string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() {
+			return nil
+		}
+		s.files++
+		s.size += fi.Size()
+		if fi.ModTime().After(s.modTime) {
+			s.modTime = fi.ModTime()
+		}
+		return nil
+	})
+	return s
+}
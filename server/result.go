@@ -0,0 +1,97 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/getgauge/html-report/buildinfo"
+	"github.com/getgauge/html-report/gauge_messages"
+	"github.com/getgauge/html-report/generator"
+	"github.com/golang/protobuf/proto"
+)
+
+var errNoResultYet = errors.New("the report has not been generated yet")
+
+// Result is the execution result served by the result api. A report that is regenerated while
+// it is served sets the result it was generated from, so that the server does not convert the
+// saved result once more. It is safe for concurrent use.
+type Result struct {
+	mu  sync.RWMutex
+	res *generator.SuiteResult
+}
+
+// Set replaces the served result.
+func (r *Result) Set(res *generator.SuiteResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.res = res
+}
+
+func (r *Result) get() *generator.SuiteResult {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.res
+}
+
+// inputResult is the result converted from the saved execution result given as input. Converting
+// a result sets up the generator for the project, so it is converted once and again only when the
+// input file changes.
+type inputResult struct {
+	mu          sync.Mutex
+	inputFile   string
+	projectRoot string
+	res         *generator.SuiteResult
+	size        int64
+	modTime     time.Time
+}
+
+func (r *inputResult) get() (*generator.SuiteResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fi, err := os.Stat(r.inputFile)
+	if err != nil {
+		return nil, err
+	}
+	if r.res != nil && fi.Size() == r.size && fi.ModTime().Equal(r.modTime) {
+		return r.res, nil
+	}
+	res, err := readResult(r.inputFile, r.projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	r.res, r.size, r.modTime = res, fi.Size(), fi.ModTime()
+	return res, nil
+}
+
+func readResult(inputFile, projectRoot string) (*generator.SuiteResult, error) {
+	b, err := ioutil.ReadFile(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	psr := &gauge_messages.ProtoSuiteResult{}
+	if err := proto.Unmarshal(b, psr); err != nil {
+		return nil, fmt.Errorf("unable to read last run data from %s. Error: %s", inputFile, err.Error())
+	}
+	return generator.ToSuiteResultWithBuild(projectRoot, psr, buildinfo.Load(inputFile)), nil
+}
@@ -0,0 +1,195 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/getgauge/html-report/generator"
+)
+
+const (
+	resultPath     = "/api/result.json"
+	eventsPath     = "/api/events"
+	jsonResultFile = "result.json"
+	indexFile      = "index.html"
+	pollInterval   = time.Second
+)
+
+// reloadScript reloads the page when the server reports that the report was regenerated.
+const reloadScript = `<script>new EventSource("` + eventsPath + `").onmessage = function() { location.reload(); };</script>`
+
+// Options configure how a report directory is served.
+type Options struct {
+	// Addr is the address to listen on. A free port on localhost is used if empty.
+	Addr string
+	// Watch makes the server reload open pages when the files of the report change.
+	Watch bool
+	// InputFile is a saved execution result (last_run_result) that backs the result api.
+	// The result.json of the report directory is used if empty.
+	InputFile string
+	// ProjectRoot is the root of the Gauge project the result belongs to.
+	ProjectRoot string
+	// Result is set by the report regenerated while it is served. It backs the result api
+	// instead of InputFile, which is then not converted by the server.
+	Result *Result
+}
+
+type reportServer struct {
+	dir      string
+	opts     Options
+	reloader *reloader
+	input    *inputResult
+}
+
+// Serve serves the report in dir until the server fails.
+func Serve(dir string, opts Options) error {
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		return fmt.Errorf("report directory does not exist: %s", dir)
+	}
+	addr := opts.Addr
+	if addr == "" {
+		addr = "localhost:0"
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s := newReportServer(dir, opts)
+	if s.input != nil {
+		if _, err := s.input.get(); err != nil {
+			log.Printf("[Warning] %s\n", err.Error())
+		}
+	}
+	if s.reloader != nil {
+		go watch(dir, pollInterval, s.reloader.notify)
+	}
+	fmt.Printf("Serving html-report from %s at http://%s\n", dir, l.Addr().String())
+	return http.Serve(l, s.handler())
+}
+
+func newReportServer(dir string, opts Options) *reportServer {
+	s := &reportServer{dir: dir, opts: opts}
+	if opts.InputFile != "" && opts.Result == nil {
+		s.input = &inputResult{inputFile: opts.InputFile, projectRoot: opts.ProjectRoot}
+	}
+	if opts.Watch {
+		s.reloader = newReloader()
+	}
+	return s
+}
+
+func (s *reportServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(resultPath, s.serveResult)
+	if s.reloader != nil {
+		mux.Handle(eventsPath, s.reloader)
+	}
+	mux.HandleFunc("/", s.serveFile)
+	return gzipHandler(mux)
+}
+
+func (s *reportServer) serveFile(w http.ResponseWriter, r *http.Request) {
+	p := path.Clean("/" + r.URL.Path)
+	if strings.HasSuffix(r.URL.Path, "/") {
+		p = path.Join(p, indexFile)
+	}
+	file := filepath.Join(s.dir, filepath.FromSlash(p))
+	fi, err := os.Stat(file)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if fi.IsDir() {
+		http.Redirect(w, r, p+"/", http.StatusMovedPermanently)
+		return
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType(file))
+	if s.reloader != nil && path.Ext(p) == ".html" {
+		b = injectReloadScript(b)
+	}
+	http.ServeContent(w, r, fi.Name(), fi.ModTime(), bytes.NewReader(b))
+}
+
+// serveResult serves the execution result, converted the same way as for the json report.
+func (s *reportServer) serveResult(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Result == nil && s.input == nil {
+		file := filepath.Join(s.dir, jsonResultFile)
+		if _, err := os.Stat(file); err != nil {
+			http.Error(w, "No execution result available. Generate the report with the json format or pass the saved result with --input.", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", contentType(file))
+		http.ServeFile(w, r, file)
+		return
+	}
+	res, err := s.result()
+	if err == errNoResultYet {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		log.Printf("[Warning] %s\n", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	b, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType(jsonResultFile))
+	w.Write(b)
+}
+
+func (s *reportServer) result() (*generator.SuiteResult, error) {
+	if s.input != nil {
+		return s.input.get()
+	}
+	if res := s.opts.Result.get(); res != nil {
+		return res, nil
+	}
+	return nil, errNoResultYet
+}
+
+func injectReloadScript(b []byte) []byte {
+	i := bytes.LastIndex(b, []byte("</body>"))
+	if i < 0 {
+		return append(b, reloadScript...)
+	}
+	res := make([]byte, 0, len(b)+len(reloadScript))
+	res = append(res, b[:i]...)
+	res = append(res, reloadScript...)
+	return append(res, b[i:]...)
+}
@@ -0,0 +1,299 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getgauge/html-report/buildinfo"
+	"github.com/getgauge/html-report/generator"
+	"github.com/getgauge/html-report/model"
+)
+
+func reportDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "html-report-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"index.html":        "<html><body><p>index</p></body></html>",
+		"css/style.css":     "body { color: red; }",
+		"js/main.js":        "var a = 1;",
+		"images/logo.png":   "\x89PNG\r\n\x1a\n",
+		"nested/index.html": "<html><body>nested</body></html>",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func get(t *testing.T, h http.Handler, path string, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", path, nil)
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestServeFileContentTypes(t *testing.T) {
+	dir := reportDir(t)
+	defer os.RemoveAll(dir)
+	h := newReportServer(dir, Options{}).handler()
+
+	tests := []struct {
+		path        string
+		contentType string
+	}{
+		{"/", "text/html; charset=utf-8"},
+		{"/index.html", "text/html; charset=utf-8"},
+		{"/css/style.css", "text/css; charset=utf-8"},
+		{"/js/main.js", "application/javascript; charset=utf-8"},
+		{"/images/logo.png", "image/png"},
+		{"/nested/", "text/html; charset=utf-8"},
+	}
+	for _, test := range tests {
+		w := get(t, h, test.path, nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: want status %d, got %d", test.path, http.StatusOK, w.Code)
+		}
+		if got := w.Header().Get("Content-Type"); got != test.contentType {
+			t.Errorf("%s: want: %q, got: %q", test.path, test.contentType, got)
+		}
+	}
+}
+
+func TestServeFileOutsideReportDir(t *testing.T) {
+	dir := reportDir(t)
+	defer os.RemoveAll(dir)
+	s := newReportServer(filepath.Join(dir, "nested"), Options{})
+
+	w := get(t, http.HandlerFunc(s.serveFile), "/../css/style.css", nil)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("want status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestServeFileRedirectsDirectories(t *testing.T) {
+	dir := reportDir(t)
+	defer os.RemoveAll(dir)
+	h := newReportServer(dir, Options{}).handler()
+
+	w := get(t, h, "/nested", nil)
+
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/nested/" {
+		t.Errorf("want redirect to /nested/, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestServeFileCompressesText(t *testing.T) {
+	dir := reportDir(t)
+	defer os.RemoveAll(dir)
+	h := newReportServer(dir, Options{}).handler()
+
+	w := get(t, h, "/css/style.css", map[string]string{"Accept-Encoding": "gzip, deflate"})
+
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("want gzip encoding, got %q", w.Header().Get("Content-Encoding"))
+	}
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(gz)
+	if string(b) != "body { color: red; }" {
+		t.Errorf("want: %q, got: %q", "body { color: red; }", string(b))
+	}
+
+	w = get(t, h, "/images/logo.png", map[string]string{"Accept-Encoding": "gzip"})
+	if w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("want images to not be compressed, got %q", w.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestServeResultFromReportDir(t *testing.T) {
+	dir := reportDir(t)
+	defer os.RemoveAll(dir)
+	h := newReportServer(dir, Options{}).handler()
+
+	w := get(t, h, resultPath, nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("want status %d without a result, got %d", http.StatusNotFound, w.Code)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, jsonResultFile), []byte(`{"ProjectName":"foo"}`), 0644)
+	w = get(t, h, resultPath, nil)
+	if w.Code != http.StatusOK || w.Body.String() != `{"ProjectName":"foo"}` {
+		t.Errorf("want the result of the report, got %d %q", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+		t.Errorf("want: %q, got: %q", "application/json; charset=utf-8", got)
+	}
+}
+
+func TestServeResultFromInputFile(t *testing.T) {
+	dir := reportDir(t)
+	defer os.RemoveAll(dir)
+	input := filepath.Join("..", "regenerate", "_testdata", "last_run_result")
+	h := newReportServer(dir, Options{InputFile: input}).handler()
+
+	w := get(t, h, resultPath, nil)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"SpecResults"`) {
+		t.Errorf("want the converted suite result, got %q", w.Body.String())
+	}
+}
+
+func TestServeResultWithBuildKeptWithInputFile(t *testing.T) {
+	dir := reportDir(t)
+	defer os.RemoveAll(dir)
+	b, err := ioutil.ReadFile(filepath.Join("..", "regenerate", "_testdata", "last_run_result"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := filepath.Join(dir, "last_run_result")
+	ioutil.WriteFile(input, b, 0644)
+	buildinfo.Save(input, &model.BuildInfo{Commit: "0123456789"})
+	h := newReportServer(dir, Options{InputFile: input}).handler()
+
+	w := get(t, h, resultPath, nil)
+
+	if !strings.Contains(w.Body.String(), `"Commit": "0123456789"`) {
+		t.Errorf("want the build kept with the input file, got %q", w.Body.String())
+	}
+}
+
+func TestServeResultConvertsInputFileOnlyWhenItChanges(t *testing.T) {
+	dir := reportDir(t)
+	defer os.RemoveAll(dir)
+	b, err := ioutil.ReadFile(filepath.Join("..", "regenerate", "_testdata", "last_run_result"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := filepath.Join(dir, "last_run_result")
+	ioutil.WriteFile(input, b, 0644)
+	s := newReportServer(dir, Options{InputFile: input})
+	h := s.handler()
+
+	get(t, h, resultPath, nil)
+	first := s.input.res
+	get(t, h, resultPath, nil)
+
+	if first == nil || s.input.res != first {
+		t.Errorf("want the converted result to be reused")
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(input, later, later)
+	get(t, h, resultPath, nil)
+	if s.input.res == first {
+		t.Errorf("want the result to be converted again once the input file changed")
+	}
+}
+
+func TestServeResultSetByRegeneratedReport(t *testing.T) {
+	dir := reportDir(t)
+	defer os.RemoveAll(dir)
+	result := &Result{}
+	h := newReportServer(dir, Options{InputFile: filepath.Join(dir, "missing"), Result: result}).handler()
+
+	if w := get(t, h, resultPath, nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("want status %d before the report is generated, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	result.Set(&generator.SuiteResult{ProjectName: "regenerated"})
+
+	w := get(t, h, resultPath, nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"ProjectName": "regenerated"`) {
+		t.Errorf("want the result set by the regenerated report, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestServeFileInjectsReloadScriptWhenWatching(t *testing.T) {
+	dir := reportDir(t)
+	defer os.RemoveAll(dir)
+
+	w := get(t, newReportServer(dir, Options{Watch: true}).handler(), "/index.html", nil)
+	want := "<html><body><p>index</p>" + reloadScript + "</body></html>"
+	if w.Body.String() != want {
+		t.Errorf("want: %q, got: %q", want, w.Body.String())
+	}
+
+	w = get(t, newReportServer(dir, Options{}).handler(), "/index.html", nil)
+	if strings.Contains(w.Body.String(), "EventSource") {
+		t.Errorf("want no reload script when not watching, got %q", w.Body.String())
+	}
+}
+
+func TestReloaderNotifiesListeningPages(t *testing.T) {
+	rl := newReloader()
+	srv := httptest.NewServer(gzipHandler(rl))
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" || resp.Header.Get("Content-Encoding") != "" {
+		t.Fatalf("want an uncompressed event stream, got %q %q", resp.Header.Get("Content-Type"), resp.Header.Get("Content-Encoding"))
+	}
+
+	buf := make([]byte, 64)
+	n, _ := resp.Body.Read(buf)
+	if !strings.HasPrefix(string(buf[:n]), ": connected") {
+		t.Fatalf("want the connected comment, got %q", string(buf[:n]))
+	}
+	rl.notify()
+	n, _ = resp.Body.Read(buf)
+	if string(buf[:n]) != "data: reload\n\n" {
+		t.Errorf("want: %q, got: %q", "data: reload\n\n", string(buf[:n]))
+	}
+}
+
+func TestSnapshotChangesWhenFilesChange(t *testing.T) {
+	dir := reportDir(t)
+	defer os.RemoveAll(dir)
+	before := snapshot(dir)
+
+	p := filepath.Join(dir, "index.html")
+	ioutil.WriteFile(p, []byte("<html><body>regenerated</body></html>"), 0644)
+	os.Chtimes(p, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+
+	if snapshot(dir) == before {
+		t.Error("want the snapshot to change when a file changes")
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package stream reports the progress of an execution as it happens, in the TAP or TeamCity
+// service message format, from the execution events Gauge sends to the plugin.
+package stream
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/getgauge/html-report/gauge_messages"
+	"github.com/getgauge/html-report/listener"
+)
+
+const (
+	// TAP streams TAP version 13.
+	TAP = "tap"
+	// TeamCity streams TeamCity service messages.
+	TeamCity = "teamcity"
+)
+
+// test is the outcome of a scenario. Gauge does not send the error a step failed with along with
+// the execution events, so Message is the failed step and the first line of the stack trace,
+// which holds the error for most language runners. Message of a skipped test is the reason it
+// was skipped for.
+type test struct {
+	Suite    string
+	Name     string
+	Failed   bool
+	Skipped  bool
+	Message  string
+	Details  string
+	Duration time.Duration
+}
+
+// reporter writes the progress of an execution in a specific format. Suites are specs and tests
+// are scenarios.
+type reporter interface {
+	started()
+	suiteStarted(name string)
+	testStarted(suite, name string)
+	testFinished(t *test)
+	suiteFinished(name string)
+	finished()
+}
+
+// Attach streams the progress of the execution l listens to, to w in the given format.
+func Attach(l *listener.GaugeListener, w io.Writer, format string) error {
+	r, err := newReporter(w, format)
+	if err != nil {
+		return err
+	}
+	s := &streamer{reporter: r, now: time.Now, streamed: make(map[string]int)}
+	l.OnExecutionStarting(s.executionStarting)
+	l.OnSpecStarting(s.specStarting)
+	l.OnScenarioStarting(s.scenarioStarting)
+	l.OnStepEnding(s.stepEnding)
+	l.OnScenarioEnding(s.scenarioEnding)
+	l.OnSpecEnding(s.specEnding)
+	l.OnSuiteResult(s.suiteResult)
+	return nil
+}
+
+func newReporter(w io.Writer, format string) (reporter, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case TAP:
+		return &tapReporter{w: w}, nil
+	case TeamCity:
+		return &teamCityReporter{w: w}, nil
+	}
+	return nil, fmt.Errorf("unknown stream format '%s'", format)
+}
+
+// streamer keeps track of the spec and scenario being executed. Gauge sends the events of a
+// scenario in order, so that a failed step is the step of the scenario that last started.
+// Gauge sends no events for most of the scenarios it skips, which are streamed from the suite
+// result once the execution is over. streamed counts the scenarios streamed for each heading,
+// as the rows of a table driven scenario share theirs.
+type streamer struct {
+	reporter      reporter
+	now           func() time.Time
+	spec          string
+	scenario      string
+	scenarioStart time.Time
+	failedStep    string
+	stacktrace    string
+	streamed      map[string]int
+}
+
+func (s *streamer) executionStarting(info *gauge_messages.ExecutionInfo) {
+	s.reporter.started()
+}
+
+func (s *streamer) specStarting(info *gauge_messages.ExecutionInfo) {
+	s.spec = info.GetCurrentSpec().GetName()
+	s.reporter.suiteStarted(s.spec)
+}
+
+func (s *streamer) scenarioStarting(info *gauge_messages.ExecutionInfo) {
+	s.scenario = info.GetCurrentScenario().GetName()
+	s.scenarioStart = s.now()
+	s.failedStep, s.stacktrace = "", ""
+	s.reporter.testStarted(s.spec, s.scenario)
+}
+
+func (s *streamer) stepEnding(info *gauge_messages.ExecutionInfo) {
+	if info.GetCurrentStep().GetIsFailed() && s.failedStep == "" {
+		s.failedStep = info.GetCurrentStep().GetStep().GetActualStepText()
+		s.stacktrace = info.GetStacktrace()
+	}
+}
+
+func (s *streamer) scenarioEnding(info *gauge_messages.ExecutionInfo) {
+	t := &test{
+		Suite:    s.spec,
+		Name:     s.scenario,
+		Failed:   info.GetCurrentScenario().GetIsFailed(),
+		Duration: s.now().Sub(s.scenarioStart),
+	}
+	if t.Failed {
+		t.Details = s.stacktrace
+		if t.Details == "" {
+			t.Details = info.GetStacktrace()
+		}
+		t.Message = failureMessage(s.failedStep, t.Details)
+	}
+	s.streamed[testKey(t.Suite, t.Name)]++
+	s.reporter.testFinished(t)
+}
+
+func (s *streamer) specEnding(info *gauge_messages.ExecutionInfo) {
+	s.reporter.suiteFinished(s.spec)
+}
+
+// suiteResult streams the skipped scenarios of each spec and ends the stream. Gauge sends the
+// suite result after the end of the execution.
+func (s *streamer) suiteResult(res *gauge_messages.SuiteExecutionResult) {
+	for _, specRes := range res.GetSuiteResult().GetSpecResults() {
+		spec := specRes.GetProtoSpec().GetSpecHeading()
+		scns := scenarios(specRes.GetProtoSpec())
+		// Executed scenarios were all streamed, so what is left of the count of a heading is the
+		// number of its skipped scenarios that Gauge sent events for.
+		streamedSkips := make(map[string]int)
+		for _, scn := range scns {
+			key := testKey(spec, scn.GetScenarioHeading())
+			if _, ok := streamedSkips[key]; !ok {
+				streamedSkips[key] = s.streamed[key]
+			}
+			if !isSkipped(scn) {
+				streamedSkips[key]--
+			}
+		}
+		var skipped []*test
+		for _, scn := range scns {
+			key := testKey(spec, scn.GetScenarioHeading())
+			if !isSkipped(scn) {
+				continue
+			}
+			if streamedSkips[key] > 0 {
+				streamedSkips[key]--
+				continue
+			}
+			skipped = append(skipped, &test{Suite: spec, Name: scn.GetScenarioHeading(), Skipped: true, Message: skipMessage(scn.GetSkipErrors())})
+		}
+		if len(skipped) == 0 {
+			continue
+		}
+		s.reporter.suiteStarted(spec)
+		for _, t := range skipped {
+			s.reporter.testStarted(spec, t.Name)
+			s.reporter.testFinished(t)
+		}
+		s.reporter.suiteFinished(spec)
+	}
+	s.reporter.finished()
+}
+
+// scenarios returns the scenarios of spec, including each row of a table driven scenario.
+func scenarios(spec *gauge_messages.ProtoSpec) []*gauge_messages.ProtoScenario {
+	var scns []*gauge_messages.ProtoScenario
+	for _, item := range spec.GetItems() {
+		switch item.GetItemType() {
+		case gauge_messages.ProtoItem_Scenario:
+			scns = append(scns, item.GetScenario())
+		case gauge_messages.ProtoItem_TableDrivenScenario:
+			scns = append(scns, item.GetTableDrivenScenario().GetScenario())
+		}
+	}
+	return scns
+}
+
+func isSkipped(scn *gauge_messages.ProtoScenario) bool {
+	return scn.GetSkipped() || scn.GetExecutionStatus() == gauge_messages.ExecutionStatus_SKIPPED
+}
+
+func testKey(suite, name string) string {
+	return suite + "\x00" + name
+}
+
+func skipMessage(errs []string) string {
+	for _, e := range errs {
+		if e = strings.TrimSpace(e); e != "" {
+			return tapLine(e)
+		}
+	}
+	return "Scenario skipped"
+}
+
+func failureMessage(step, stacktrace string) string {
+	var parts []string
+	if step != "" {
+		parts = append(parts, "Step failed: "+step)
+	}
+	for _, l := range strings.Split(stacktrace, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			parts = append(parts, l)
+			break
+		}
+	}
+	if len(parts) == 0 {
+		return "Scenario failed"
+	}
+	return strings.Join(parts, ": ")
+}
@@ -0,0 +1,210 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package stream
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	gm "github.com/getgauge/html-report/gauge_messages"
+	"github.com/getgauge/html-report/listener"
+	fakegauge "github.com/getgauge/html-report/test_helper/fake_gauge"
+)
+
+func specInfo(name string) *gm.ExecutionInfo {
+	return &gm.ExecutionInfo{CurrentSpec: &gm.SpecInfo{Name: name}}
+}
+
+func scenarioInfo(name string, failed bool) *gm.ExecutionInfo {
+	return &gm.ExecutionInfo{CurrentScenario: &gm.ScenarioInfo{Name: name, IsFailed: failed}}
+}
+
+func stepInfo(text string, failed bool, stacktrace string) *gm.ExecutionInfo {
+	return &gm.ExecutionInfo{
+		CurrentStep: &gm.StepInfo{Step: &gm.ExecuteStepRequest{ActualStepText: text}, IsFailed: failed},
+		Stacktrace:  stacktrace,
+	}
+}
+
+func scenarioItem(heading string, status gm.ExecutionStatus, skipErrors ...string) *gm.ProtoItem {
+	return &gm.ProtoItem{ItemType: gm.ProtoItem_Scenario, Scenario: &gm.ProtoScenario{
+		ScenarioHeading: heading, ExecutionStatus: status, Skipped: status == gm.ExecutionStatus_SKIPPED, SkipErrors: skipErrors,
+	}}
+}
+
+// execute streams a spec with a passing and a failing scenario, each taking 5ms, and a skipped
+// scenario Gauge sends no events for.
+func execute(t *testing.T, format string) string {
+	var b bytes.Buffer
+	r, err := newReporter(&b, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Unix(0, 0)
+	s := &streamer{reporter: r, streamed: make(map[string]int), now: func() time.Time {
+		clock = clock.Add(5 * time.Millisecond)
+		return clock
+	}}
+	s.executionStarting(&gm.ExecutionInfo{})
+	s.specStarting(specInfo("Words #1"))
+	s.scenarioStarting(scenarioInfo("Count", false))
+	s.stepEnding(stepInfo("Count words", false, ""))
+	s.scenarioEnding(scenarioInfo("Count", false))
+	s.scenarioStarting(scenarioInfo("Fail 'quoted' [x]", true))
+	s.stepEnding(stepInfo("Check |count|", true, "AssertionError: want 2\n\tat Words.java:12"))
+	s.stepEnding(stepInfo("Later step", true, "other"))
+	s.scenarioEnding(scenarioInfo("Fail 'quoted' [x]", true))
+	s.specEnding(specInfo("Words #1"))
+	s.suiteResult(&gm.SuiteExecutionResult{SuiteResult: &gm.ProtoSuiteResult{SpecResults: []*gm.ProtoSpecResult{{
+		ProtoSpec: &gm.ProtoSpec{SpecHeading: "Words #1", Items: []*gm.ProtoItem{
+			scenarioItem("Count", gm.ExecutionStatus_PASSED),
+			scenarioItem("Fail 'quoted' [x]", gm.ExecutionStatus_FAILED),
+			scenarioItem("Unimplemented", gm.ExecutionStatus_SKIPPED, "Step implementation not found => 'Split #words'\n"),
+		}},
+	}}}})
+	return b.String()
+}
+
+func TestStreamTAP(t *testing.T) {
+	want := `TAP version 13
+# Words #1
+ok 1 - Words \#1: Count
+not ok 2 - Words \#1: Fail 'quoted' [x]
+  ---
+  message: |-
+    Step failed: Check |count|: AssertionError: want 2
+  severity: fail
+  duration_ms: 5
+  stack: |-
+    AssertionError: want 2
+    	at Words.java:12
+  ...
+# Words #1
+ok 3 - Words \#1: Unimplemented # SKIP Step implementation not found => 'Split \#words'
+1..3
+`
+
+	got := execute(t, TAP)
+
+	if got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestStreamTeamCity(t *testing.T) {
+	want := `##teamcity[testSuiteStarted name='Words #1']
+##teamcity[testStarted name='Count' captureStandardOutput='false']
+##teamcity[testFinished name='Count' duration='5']
+##teamcity[testStarted name='Fail |'quoted|' |[x|]' captureStandardOutput='false']
+##teamcity[testFailed name='Fail |'quoted|' |[x|]' message='Step failed: Check ||count||: AssertionError: want 2' details='AssertionError: want 2|n	at Words.java:12']
+##teamcity[testFinished name='Fail |'quoted|' |[x|]' duration='5']
+##teamcity[testSuiteFinished name='Words #1']
+##teamcity[testSuiteStarted name='Words #1']
+##teamcity[testStarted name='Unimplemented' captureStandardOutput='false']
+##teamcity[testIgnored name='Unimplemented' message='Step implementation not found => |'Split #words|'']
+##teamcity[testFinished name='Unimplemented' duration='0']
+##teamcity[testSuiteFinished name='Words #1']
+`
+
+	got := execute(t, TeamCity)
+
+	if got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func tableDrivenItem(heading string, status gm.ExecutionStatus) *gm.ProtoItem {
+	return &gm.ProtoItem{ItemType: gm.ProtoItem_TableDrivenScenario, TableDrivenScenario: &gm.ProtoTableDrivenScenario{
+		Scenario: scenarioItem(heading, status).GetScenario(),
+	}}
+}
+
+func TestStreamSkippedRowsOfTableDrivenScenario(t *testing.T) {
+	var b bytes.Buffer
+	s := &streamer{reporter: &tapReporter{w: &b}, streamed: make(map[string]int), now: time.Now}
+	s.executionStarting(&gm.ExecutionInfo{})
+	s.specStarting(specInfo("Rows"))
+	for i := 0; i < 2; i++ {
+		s.scenarioStarting(scenarioInfo("Row", false))
+		s.scenarioEnding(scenarioInfo("Row", false))
+	}
+	s.specEnding(specInfo("Rows"))
+	s.suiteResult(&gm.SuiteExecutionResult{SuiteResult: &gm.ProtoSuiteResult{SpecResults: []*gm.ProtoSpecResult{{
+		ProtoSpec: &gm.ProtoSpec{SpecHeading: "Rows", Items: []*gm.ProtoItem{
+			tableDrivenItem("Row", gm.ExecutionStatus_SKIPPED),
+			tableDrivenItem("Row", gm.ExecutionStatus_PASSED),
+			tableDrivenItem("Row", gm.ExecutionStatus_SKIPPED),
+			tableDrivenItem("Row", gm.ExecutionStatus_SKIPPED),
+		}},
+	}}}})
+
+	if got := strings.Count(b.String(), "# SKIP"); got != 2 {
+		t.Errorf("want: 2 skipped rows, got:\n%s", b.String())
+	}
+	if !strings.HasSuffix(b.String(), "1..4\n") {
+		t.Errorf("want: 4 tests, got:\n%s", b.String())
+	}
+}
+
+func TestAttachRejectsUnknownFormats(t *testing.T) {
+	if err := Attach(nil, &bytes.Buffer{}, "junit"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestAttachStreamsListenerEvents(t *testing.T) {
+	event := func(messageType gm.Message_MessageType, info *gm.ExecutionInfo) *gm.Message {
+		m := &gm.Message{MessageType: messageType}
+		switch messageType {
+		case gm.Message_SpecExecutionStarting:
+			m.SpecExecutionStartingRequest = &gm.SpecExecutionStartingRequest{CurrentExecutionInfo: info}
+		case gm.Message_ScenarioExecutionStarting:
+			m.ScenarioExecutionStartingRequest = &gm.ScenarioExecutionStartingRequest{CurrentExecutionInfo: info}
+		case gm.Message_ScenarioExecutionEnding:
+			m.ScenarioExecutionEndingRequest = &gm.ScenarioExecutionEndingRequest{CurrentExecutionInfo: info}
+		}
+		return m
+	}
+	gauge, err := fakegauge.Start(
+		event(gm.Message_SpecExecutionStarting, specInfo("Words")),
+		event(gm.Message_ScenarioExecutionStarting, scenarioInfo("Count", false)),
+		event(gm.Message_ScenarioExecutionEnding, scenarioInfo("Count", false)),
+		fakegauge.KillProcessMessage(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gauge.Close()
+	l, err := listener.NewGaugeListener("127.0.0.1", gauge.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+
+	if err := Attach(l, &b, "TAP"); err != nil {
+		t.Fatal(err)
+	}
+	l.Start(context.Background())
+
+	if want := "# Words\nok 1 - Words: Count\n"; b.String() != want {
+		t.Errorf("want: %q, got: %q", want, b.String())
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package stream
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// tapReporter writes TAP version 13. Each spec starts with a comment naming it, each scenario is
+// a test point and failures carry a YAML block with the message and the stack trace. Skipped
+// scenarios are test points with a SKIP directive. The plan is written once the execution is over.
+type tapReporter struct {
+	w     io.Writer
+	count int
+}
+
+func (r *tapReporter) started() {
+	fmt.Fprintln(r.w, "TAP version 13")
+}
+
+func (r *tapReporter) suiteStarted(name string) {
+	fmt.Fprintf(r.w, "# %s\n", tapLine(name))
+}
+
+func (r *tapReporter) testStarted(suite, name string) {
+}
+
+func (r *tapReporter) testFinished(t *test) {
+	r.count++
+	status := "ok"
+	if t.Failed {
+		status = "not ok"
+	}
+	if t.Skipped {
+		fmt.Fprintf(r.w, "ok %d - %s # SKIP %s\n", r.count, tapDescription(t.Suite+": "+t.Name), tapDescription(t.Message))
+		return
+	}
+	fmt.Fprintf(r.w, "%s %d - %s\n", status, r.count, tapDescription(t.Suite+": "+t.Name))
+	if !t.Failed {
+		return
+	}
+	fmt.Fprintln(r.w, "  ---")
+	fmt.Fprintf(r.w, "  message: %s\n", yamlBlock(t.Message))
+	fmt.Fprintln(r.w, "  severity: fail")
+	fmt.Fprintf(r.w, "  duration_ms: %d\n", t.Duration.Nanoseconds()/1000000)
+	if strings.TrimSpace(t.Details) != "" {
+		fmt.Fprintf(r.w, "  stack: %s\n", yamlBlock(t.Details))
+	}
+	fmt.Fprintln(r.w, "  ...")
+}
+
+func (r *tapReporter) suiteFinished(name string) {
+}
+
+func (r *tapReporter) finished() {
+	fmt.Fprintf(r.w, "1..%d\n", r.count)
+}
+
+// tapLine keeps text on a single line.
+func tapLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// tapDescription escapes the characters that would end the description of a test point.
+func tapDescription(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "#", "\\#").Replace(tapLine(s))
+}
+
+// yamlBlock writes s as a literal block scalar, indented to be a value of the YAML block of a
+// test point.
+func yamlBlock(s string) string {
+	lines := strings.Split(strings.TrimRight(strings.Replace(s, "\r\n", "\n", -1), "\n"), "\n")
+	return "|-\n    " + strings.Join(lines, "\n    ")
+}
@@ -0,0 +1,75 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package stream
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// teamCityReporter writes TeamCity service messages, a test suite for each spec and a test for
+// each of its scenarios. Skipped scenarios are ignored tests.
+type teamCityReporter struct {
+	w io.Writer
+}
+
+func (r *teamCityReporter) started() {
+}
+
+func (r *teamCityReporter) suiteStarted(name string) {
+	r.message("testSuiteStarted", "name", name)
+}
+
+func (r *teamCityReporter) testStarted(suite, name string) {
+	r.message("testStarted", "name", name, "captureStandardOutput", "false")
+}
+
+func (r *teamCityReporter) testFinished(t *test) {
+	if t.Skipped {
+		r.message("testIgnored", "name", t.Name, "message", t.Message)
+	}
+	if t.Failed {
+		r.message("testFailed", "name", t.Name, "message", t.Message, "details", t.Details)
+	}
+	r.message("testFinished", "name", t.Name, "duration", fmt.Sprintf("%d", t.Duration.Nanoseconds()/1000000))
+}
+
+func (r *teamCityReporter) suiteFinished(name string) {
+	r.message("testSuiteFinished", "name", name)
+}
+
+func (r *teamCityReporter) finished() {
+}
+
+// message writes a service message with the given attributes, as pairs of names and values.
+func (r *teamCityReporter) message(name string, attrs ...string) {
+	var b bytes.Buffer
+	b.WriteString("##teamcity[" + name)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		fmt.Fprintf(&b, " %s='%s'", attrs[i], teamCityEscaper.Replace(attrs[i+1]))
+	}
+	b.WriteString("]\n")
+	io.WriteString(r.w, b.String())
+}
+
+var teamCityEscaper = strings.NewReplacer(
+	"|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]",
+	"\u0085", "|x", "\u2028", "|l", "\u2029", "|p",
+)
@@ -0,0 +1,107 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+package fakegauge
+
+import (
+	"path/filepath"
+
+	gm "github.com/getgauge/html-report/gauge_messages"
+)
+
+// SuiteResultMessage is the message Gauge sends with the result of the suite once it is executed.
+func SuiteResultMessage(res *gm.ProtoSuiteResult) *gm.Message {
+	return &gm.Message{
+		MessageType:          gm.Message_SuiteExecutionResult,
+		SuiteExecutionResult: &gm.SuiteExecutionResult{SuiteResult: res},
+	}
+}
+
+// KillProcessMessage is the message Gauge sends when the plugin is to stop.
+func KillProcessMessage() *gm.Message {
+	return &gm.Message{
+		MessageType:        gm.Message_KillProcessRequest,
+		KillProcessRequest: &gm.KillProcessRequest{},
+	}
+}
+
+// SuiteResult is a suite of a project at projectRoot with a passing and a failing spec in
+// its specs directory, each with a single scenario.
+func SuiteResult(projectRoot string) *gm.ProtoSuiteResult {
+	return &gm.ProtoSuiteResult{
+		SpecResults: []*gm.ProtoSpecResult{
+			PassingSpecResult(filepath.Join(projectRoot, "specs", "passing_specification.spec")),
+			FailingSpecResult(filepath.Join(projectRoot, "specs", "failing_specification.spec")),
+		},
+		Failed:           true,
+		SpecsFailedCount: 1,
+		ExecutionTime:    122609,
+		SuccessRate:      50,
+		Environment:      "default",
+		ProjectName:      "Gauge Project",
+		Timestamp:        "Jul 13, 2016 at 11:49am",
+	}
+}
+
+// PassingSpecResult is the result of a spec at fileName whose only scenario passes.
+func PassingSpecResult(fileName string) *gm.ProtoSpecResult {
+	return specResult("Passing Specification", fileName, scenario("Passing scenario", false))
+}
+
+// FailingSpecResult is the result of a spec at fileName whose only scenario fails.
+func FailingSpecResult(fileName string) *gm.ProtoSpecResult {
+	res := specResult("Failing Specification", fileName, scenario("Failing scenario", true))
+	res.Failed = true
+	res.ScenarioFailedCount = 1
+	return res
+}
+
+func specResult(heading, fileName string, scn *gm.ProtoScenario) *gm.ProtoSpecResult {
+	return &gm.ProtoSpecResult{
+		ExecutionTime: scn.ExecutionTime,
+		ScenarioCount: 1,
+		ProtoSpec: &gm.ProtoSpec{
+			SpecHeading: heading,
+			FileName:    fileName,
+			Items:       []*gm.ProtoItem{{ItemType: gm.ProtoItem_Scenario, Scenario: scn}},
+		},
+	}
+}
+
+func scenario(heading string, failed bool) *gm.ProtoScenario {
+	status := gm.ExecutionStatus_PASSED
+	result := &gm.ProtoExecutionResult{Failed: failed, ExecutionTime: 211316}
+	if failed {
+		status = gm.ExecutionStatus_FAILED
+		result.ErrorMessage = "expected 1 to equal 2"
+		result.StackTrace = "at step (step.go:1)"
+	}
+	return &gm.ProtoScenario{
+		ScenarioHeading: heading,
+		ExecutionStatus: status,
+		ExecutionTime:   211316,
+		ScenarioItems: []*gm.ProtoItem{{
+			ItemType: gm.ProtoItem_Step,
+			Step: &gm.ProtoStep{
+				ActualText:          "Step",
+				ParsedText:          "Step",
+				Fragments:           []*gm.Fragment{{FragmentType: gm.Fragment_Text, Text: "Step"}},
+				StepExecutionResult: &gm.ProtoStepExecutionResult{ExecutionResult: result},
+			},
+		}},
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2015 ThoughtWorks, Inc.
+
+// This file is part of getgauge/html-report.
+
+// getgauge/html-report is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// getgauge/html-report is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with getgauge/html-report.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package fakegauge provides a stand-in for Gauge that plugins can connect to in tests.
+package fakegauge
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/getgauge/html-report/gauge_messages"
+	"github.com/golang/protobuf/proto"
+)
+
+// Server listens on a local port the way Gauge does for plugins, and replays a script of
+// messages to the first plugin that connects.
+type Server struct {
+	listener net.Listener
+	script   []*gauge_messages.Message
+	done     chan struct{}
+	err      error
+}
+
+// Start starts a server on a free local port, which replays script once a plugin connects.
+func Start(script ...*gauge_messages.Message) (*Server, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{listener: l, script: script, done: make(chan struct{})}
+	go s.serve()
+	return s, nil
+}
+
+// Port is the port the server listens on, to be given to the plugin as plugin_connection_port.
+func (s *Server) Port() string {
+	return strconv.Itoa(s.listener.Addr().(*net.TCPAddr).Port)
+}
+
+// Wait waits until the script is replayed and the plugin has closed the connection.
+func (s *Server) Wait(timeout time.Duration) error {
+	select {
+	case <-s.done:
+		return s.err
+	case <-time.After(timeout):
+		return fmt.Errorf("plugin did not close the connection within %s", timeout)
+	}
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
+
+func (s *Server) serve() {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		s.err = err
+		return
+	}
+	defer conn.Close()
+	for _, m := range s.script {
+		if err := WriteMessage(conn, m); err != nil {
+			s.err = err
+			return
+		}
+	}
+	io.Copy(ioutil.Discard, conn)
+}
+
+// WriteMessage writes a message the way Gauge does, prefixed with its varint encoded length.
+func WriteMessage(w io.Writer, m *gauge_messages.Message) error {
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(proto.EncodeVarint(uint64(len(b))), b...))
+	return err
+}
@@ -39,16 +39,113 @@ function describeArc(x, y, radius, startAngle, endAngle) {
     ].join(" ");
 }
 
-function filterSpecList(status) {
-    $('#listOfSpecifications li.spec-name').each(function() {
-        if ($(this).hasClass(status)) {
-            $(this).show();
-        } else {
-            $(this).hide();
-        }
+var listFilters = ["status", "tag", "error", "min", "max", "sort"];
+
+// The "Verification failures only" checkbox of the sidebar is a shortcut for this error filter of the spec list.
+var verificationErrorType = "verification";
+
+// The lists that can be filtered and sorted. The state of each list is kept in the query string
+// of the page, the parameters of the scenario list are prefixed to tell them apart.
+var listScopes = {
+    spec: {
+        prefix: "",
+        container: function() { return $('#listOfSpecifications .spec-list'); },
+        items: function() { return $('#listOfSpecifications .spec-list > a'); },
+        status: function(item) { return item.children('li').attr('class'); },
+        name: function(item) { return item.find('.scenarioname').text().trim(); }
+    },
+    scenario: {
+        prefix: "scenario-",
+        container: function() { return $('#specItemsContainer .content'); },
+        items: function() { return $('#specItemsContainer .content > .scenario-container'); },
+        status: function(item) { return item.attr('class'); },
+        name: function(item) { return item.find('.scenario-head h3').text().trim(); }
+    }
+};
+
+function readListState(scope) {
+    var params = new URLSearchParams(location.search);
+    var state = {};
+    listFilters.forEach(function(f) {
+        var value = params.get(listScopes[scope].prefix + f);
+        if (value) state[f] = value;
+    });
+    return state;
+}
+
+function writeListState(scope, state) {
+    var params = new URLSearchParams(location.search);
+    listFilters.forEach(function(f) {
+        var key = listScopes[scope].prefix + f;
+        if (state[f]) { params.set(key, state[f]); } else { params.delete(key); }
+    });
+    var query = params.toString();
+    history.replaceState(null, '', location.pathname + (query ? '?' + query : '') + location.hash);
+    carrySpecListState();
+}
+
+// Keeps the state of the spec list when moving to another spec. The state of the scenario
+// list belongs to the current spec and is left behind.
+function carrySpecListState() {
+    var state = readListState("spec");
+    var params = new URLSearchParams();
+    Object.keys(state).forEach(function(f) { params.set(f, state[f]); });
+    var query = params.toString();
+    listScopes.spec.items().each(function() {
+        var href = $(this).attr('href').split('?')[0];
+        $(this).attr('href', href + (query ? '?' + query : ''));
     });
-    var specs = $(".spec-list a").filter(function() { return $(this).children().first().is(':visible'); })
-    filterSidebar(specs, $('#searchSpecifications').val().trim());
+}
+
+function matchesListState(scope, item, state) {
+    var statuses = (listScopes[scope].status(item) || "").split(/\s+/);
+    if (state.status && statuses.indexOf(state.status) < 0) return false;
+    if (state.tag && (item.attr('data-tags') || "").split(',').indexOf(state.tag) < 0) return false;
+    if (state.error && (item.attr('data-error-types') || "").split(' ').indexOf(state.error) < 0) return false;
+    var seconds = Number(item.attr('data-duration')) / 1000;
+    if (state.min && seconds < Number(state.min)) return false;
+    if (state.max && seconds > Number(state.max)) return false;
+    return true;
+}
+
+function sortList(scope, sort) {
+    var list = listScopes[scope];
+    var compare = {
+        name: function(a, b) { return list.name($(a)).localeCompare(list.name($(b))); },
+        duration: function(a, b) { return Number($(b).attr('data-duration')) - Number($(a).attr('data-duration')); },
+        source: function(a, b) { return Number($(a).attr('data-order')) - Number($(b).attr('data-order')); }
+    }[sort] || function(a, b) { return $(a).data('rendered') - $(b).data('rendered'); };
+    list.container().append(list.items().get().sort(compare));
+}
+
+function applyListState(scope) {
+    var state = readListState(scope);
+    listScopes[scope].items().each(function() {
+        $(this).toggleClass('filtered-out', !matchesListState(scope, $(this), state));
+    });
+    sortList(scope, state.sort);
+    $('.list-controls[data-scope="' + scope + '"] [data-filter]').each(function() {
+        $(this).val(state[$(this).data('filter')] || "");
+    });
+    if (scope === "spec") {
+        $('#verificationFailuresOnly').prop('checked', state.error === verificationErrorType);
+    }
+}
+
+function fillListOptions(scope) {
+    var tags = {}, errorTypes = {};
+    listScopes[scope].items().each(function() {
+        ($(this).attr('data-tags') || "").split(',').forEach(function(t) { if (t) tags[t] = true; });
+        ($(this).attr('data-error-types') || "").split(' ').forEach(function(e) { if (e) errorTypes[e] = true; });
+    });
+    var controls = $('.list-controls[data-scope="' + scope + '"]');
+    var fill = function(filter, values) {
+        var select = controls.find('[data-filter="' + filter + '"]');
+        Object.keys(values).sort().forEach(function(v) { select.append($('<option></option>').val(v).text(v)); });
+        select.toggle(Object.keys(values).length > 0);
+    };
+    fill("tag", tags);
+    fill("error", errorTypes);
 }
 
 function showFirstSpecContent() {
@@ -62,7 +159,7 @@ function filterSidebar(specsCollection, searchText) {
     if (!index) return;
     tagMatches = index.Tags[searchText];
     specsCollection.each(function() {
-        var relPath = $(this).attr('href').split("/");
+        var relPath = $(this).attr('href').split("?")[0].split("/");
         var fileName = relPath[relPath.length - 1];
         var existsIn = function(arr) {
             if (arr === undefined) {
@@ -88,6 +185,158 @@ function resetSidebar() {
     });
 }
 
+// searchShards holds the loaded full text index of each shard file and shardLoads
+// the load state of each shard file requested so far. A shard that failed to load
+// is not requested again.
+var searchShards = {};
+var shardLoads = {};
+
+function addSearchShard(file, shard) {
+    searchShards[file] = shard;
+}
+
+function reportBasePath() {
+    var src = $('script[src$="js/search_index.js"]').attr('src') || "js/search_index.js";
+    return src.substring(0, src.length - "js/search_index.js".length);
+}
+
+function tokenPrefix(token) {
+    return Array.from(token).slice(0, 2).join('');
+}
+
+// shardsFor returns the shard files that can match every one of the tokens.
+function shardsFor(tokens) {
+    if (!index || !index.Shards) return [];
+    return Object.keys(index.Shards).map(function(dir) { return index.Shards[dir]; }).filter(function(shard) {
+        return tokens.every(function(t) { return shard.Prefixes.indexOf(tokenPrefix(t)) > -1; });
+    }).map(function(shard) { return shard.File; });
+}
+
+function loadSearchShard(file, callback) {
+    var load = shardLoads[file];
+    if (!load) {
+        load = shardLoads[file] = { done: false, callbacks: [] };
+        var script = document.createElement('script');
+        script.src = reportBasePath() + "js/" + file;
+        script.onload = script.onerror = function() {
+            load.done = true;
+            load.callbacks.forEach(function(cb) { cb(); });
+            load.callbacks = [];
+        };
+        document.body.appendChild(script);
+    }
+    if (load.done) {
+        callback();
+    } else {
+        load.callbacks.push(callback);
+    }
+}
+
+function loadSearchShards(files, callback) {
+    var pending = files.length;
+    if (pending === 0) {
+        callback();
+        return;
+    }
+    files.forEach(function(file) {
+        loadSearchShard(file, function() {
+            pending--;
+            if (pending === 0) callback();
+        });
+    });
+}
+
+function tokenize(text) {
+    return text.toLowerCase().split(/[^\p{L}\p{N}]+/u).filter(function(t) { return t.length >= 2; });
+}
+
+function searchShard(shard, tokens) {
+    var matches = null;
+    var terms = Object.keys(shard.Terms);
+    tokens.forEach(function(token) {
+        var docs = {};
+        terms.forEach(function(term) {
+            if (term.startsWith(token)) {
+                shard.Terms[term].forEach(function(d) { docs[d] = true; });
+            }
+        });
+        if (matches === null) {
+            matches = docs;
+        } else {
+            Object.keys(matches).forEach(function(d) { if (!docs[d]) delete matches[d]; });
+        }
+    });
+    return Object.keys(matches || {}).map(function(d) {
+        var doc = shard.Docs[d];
+        var spec = shard.Specs[doc.s];
+        return { file: spec[0], spec: spec[1], anchor: doc.a, kind: doc.k, text: doc.t };
+    });
+}
+
+// searchCount numbers the searches, so that results of a search that finish
+// loading after a later search started are dropped.
+var searchCount = 0;
+
+function fullTextSearch(text, callback) {
+    var current = ++searchCount;
+    var tokens = tokenize(text);
+    if (tokens.length === 0) {
+        callback([]);
+        return;
+    }
+    var files = shardsFor(tokens);
+    loadSearchShards(files, function() {
+        if (current !== searchCount) return;
+        var results = [];
+        files.forEach(function(file) {
+            if (searchShards[file]) {
+                results = results.concat(searchShard(searchShards[file], tokens));
+            }
+        });
+        callback(results);
+    });
+}
+
+function showSearchResults(results) {
+    var container = $('#searchResults');
+    if (container.length === 0) {
+        container = $('<ul id="searchResults" class="search-results"></ul>').insertAfter('.searchbar');
+    }
+    container.empty();
+    if (results.length === 0) {
+        container.hide();
+        return;
+    }
+    var icons = { scenario: "list", step: "chevron-right", error: "exclamation-circle", message: "comment" };
+    var base = reportBasePath();
+    results.slice(0, 100).forEach(function(r) {
+        var link = $('<a></a>').attr('href', base + r.file + '#' + r.anchor);
+        link.append($('<i aria-hidden="true"></i>').addClass('fa fa-' + icons[r.kind]));
+        link.append($('<span class="search-text"></span>').text(r.text));
+        link.append($('<span class="search-spec"></span>').text(r.spec));
+        container.append($('<li></li>').addClass(r.kind).append(link));
+    });
+    if (results.length > 100) {
+        container.append($('<li class="more"></li>').text((results.length - 100) + " more results, refine your search"));
+    }
+    container.show();
+}
+
+function revealAnchor() {
+    if (!location.hash) return;
+    var target = $(document.getElementById(location.hash.substring(1)));
+    if (target.length === 0) return;
+    var scenario = target.closest('.scenario-container');
+    scenario.removeClass('filtered-out');
+    if (typeof scenario.data('tablerow') != 'undefined') {
+        $('.row-selector[data-rowIndex="' + scenario.data('tablerow') + '"]').click();
+    }
+    target.parents('.concept-steps').show();
+    $('.highlighted').removeClass('highlighted');
+    target.addClass('highlighted');
+    target[0].scrollIntoView();
+}
+
 function openModal(e) {
     $(this).next('.modal').css('display', 'block');
     $('body').addClass('is-modal-open');
@@ -99,11 +348,25 @@ function closeModal() {
 }
 
 var initializers = {
+    "registerListControls": function() {
+        Object.keys(listScopes).forEach(function(scope) {
+            listScopes[scope].items().each(function(i) { $(this).data('rendered', i); });
+            fillListOptions(scope);
+            applyListState(scope);
+            $('.list-controls[data-scope="' + scope + '"] [data-filter]').change(function() {
+                var state = readListState(scope);
+                state[$(this).data('filter')] = $(this).val();
+                writeListState(scope, state);
+                applyListState(scope);
+            });
+        });
+        carrySpecListState();
+    },
     "initializeFilters": function() {
-        if (sessionStorage.FilterStatus) {
-            filterSpecList(sessionStorage.FilterStatus);
+        var status = readListState("spec").status;
+        if (status) {
             $('.spec-filter').each(function() {
-                if ($(this).data('status') === sessionStorage.FilterStatus) {
+                if ($(this).data('status') === status) {
                     $(this).addClass('active');
                 }
             });
@@ -147,20 +410,21 @@ var initializers = {
                 $(this).removeClass('active');
             });
         };
+        var filterStatus = function(status) {
+            var state = readListState("spec");
+            state.status = status;
+            writeListState("spec", state);
+            applyListState("spec");
+        };
         $('.spec-filter, #pie-chart path.shadow').click(function() {
             resetState();
-            var status = $(this).data('status');
-            sessionStorage.FilterStatus = status;
-            filterSpecList(status);
+            filterStatus($(this).data('status'));
             showFirstSpecContent();
             $(this).addClass('active');
         });
         $('.total-specs').click(function() {
             resetState();
-            resetSidebar();
-            sessionStorage.removeItem('FilterStatus');
-            var specs = $(".spec-list a").filter(function() { return $(this).children().first().is(':visible'); })
-            filterSidebar(specs, $('#searchSpecifications').val().trim());
+            filterStatus("");
             showFirstSpecContent();
             $(this).addClass('active');
         });
@@ -206,9 +470,32 @@ var initializers = {
                 var specs = $(".spec-list a");
                 filterSidebar(specs, searchText);
             }
+            fullTextSearch(searchText, showSearchResults);
             showFirstSpecContent();
         });
     },
+    "registerErrorTypeFilter": function() {
+        $('#verificationFailuresOnly').change(function() {
+            var state = readListState("spec");
+            state.error = $(this).is(':checked') ? verificationErrorType : "";
+            writeListState("spec", state);
+            applyListState("spec");
+            showFirstSpecContent();
+        });
+    },
+    "registerSpecTabs": function() {
+        $('.spec-tab, .spec-source a[data-tab]').click(function() {
+            var tab = $(this).data('tab');
+            $('.spec-tab').removeClass('active');
+            $('.spec-tab[data-tab="' + tab + '"]').addClass('active');
+            $('#specItemsContainer, #specSource').addClass('hidden');
+            $('#' + tab).removeClass('hidden');
+        });
+    },
+    "registerAnchorNavigation": function() {
+        revealAnchor();
+        $(window).on('hashchange', revealAnchor);
+    },
     "registerSearchAutocomplete": function() {
         new autoComplete({
             selector: 'input[id="searchSpecifications"]',
@@ -239,6 +526,15 @@ var initializers = {
     },
     "initializeClipboard": function() {
         new Clipboard('.clipboard-btn');
+        new Clipboard('.permalink-btn', {
+            text: function(trigger) {
+                return location.href.split('#')[0] + '#' + $(trigger).data('anchor');
+            }
+        });
+        $('.permalink-btn').click(function(e) {
+            e.stopPropagation();
+            history.replaceState(null, '', '#' + $(this).data('anchor'));
+        });
     },
     "drawPieChart": function() {
         var results = $("#pie-chart").data("results").split(",").map(Number);